@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/vybtest/vyb/internal/runner"
@@ -11,69 +13,313 @@ import (
 var version = "0.1.0-alpha"
 
 func main() {
+	runner.Version = version
+
 	rootCmd := &cobra.Command{
 		Use:     "vyb",
 		Short:   "Vyb - Testing that vibes with AI",
 		Version: version,
 	}
 
-	runCmd := &cobra.Command{
-		Use:   "run [pattern]",
-		Short: "Run tests",
-		Long:  "Run all tests matching the pattern (default: **/*.test.vyb)",
+	runCmd := newRunCommand()
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Initialize Vyb in current directory",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := initializeProject(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("✅ Vyb initialized!")
+			fmt.Println("\nNext steps:")
+			fmt.Println("  1. Create a test file: example.test.vyb")
+			fmt.Println("  2. Run tests: vyb run")
+		},
+	}
+
+	resolveCmd := &cobra.Command{
+		Use:   "resolve <test>",
+		Short: "Record whether a test's latest failure was a bug in the code or in the test",
+		Long: "Record how the latest failure of a test was resolved. Resolutions are kept in .vyb/history\n" +
+			"and used to check how well stated confidence predicts real bugs (see 'vyb calibration').",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			codeBug, _ := cmd.Flags().GetBool("code-bug")
+			testBug, _ := cmd.Flags().GetBool("test-bug")
+			if codeBug == testBug {
+				fmt.Fprintln(os.Stderr, "Error: give exactly one of --code-bug or --test-bug")
+				os.Exit(1)
+			}
+			resolution := runner.ResolutionCodeBug
+			if testBug {
+				resolution = runner.ResolutionTestBug
+			}
+
+			file, _ := cmd.Flags().GetString("file")
+			file, err := runner.Resolve(args[0], file, resolution)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Recorded %s as a %s (%s)\n", args[0], strings.ReplaceAll(resolution, "_", " "), file)
+		},
+	}
+	resolveCmd.Flags().Bool("code-bug", false, "The implementation was wrong and the test was right")
+	resolveCmd.Flags().Bool("test-bug", false, "The test was wrong")
+	resolveCmd.Flags().String("file", "", "Test file, when tests in several files have this name")
+
+	calibrationCmd := &cobra.Command{
+		Use:   "calibration",
+		Short: "Show how well stated confidence predicted real bugs",
+		Long: "Compare the confidence of failed tests with how their failures were resolved, by\n" +
+			"confidence level, test file and author (from git blame).",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			by, _ := cmd.Flags().GetString("by")
+			if err := runner.CalibrationReport(os.Stdout, by); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	calibrationCmd.Flags().String("by", "", "Only group by file, author or confidence")
+
+	listCmd := &cobra.Command{
+		Use:   "list [pattern]",
+		Short: "List tests without running them",
+		Long: "List every test matching the pattern (default: **/*.test.vyb) with its file, line, tags,\n" +
+			"confidence and the functions it calls. Files are only parsed; no runtime is started.",
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			pattern := "**/*.test.vyb"
 			if len(args) > 0 {
 				pattern = args[0]
 			}
 
-			watch, _ := cmd.Flags().GetBool("watch")
-			jsonOutput, _ := cmd.Flags().GetBool("json")
-			prettyOutput, _ := cmd.Flags().GetBool("pretty")
-
-			// Default is YAML output (AI-native)
+			// Default is YAML, like vyb run
 			format := runner.OutputSuggest
-			if prettyOutput {
+			if prettyOutput, _ := cmd.Flags().GetBool("pretty"); prettyOutput {
 				format = runner.OutputPretty
 			}
-			if jsonOutput {
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
 				format = runner.OutputJSON
 			}
 
-			if err := runner.Run(pattern, watch, format); err != nil {
-				if prettyOutput {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				}
+			if err := runner.List(pattern, format, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 		},
 	}
-	runCmd.Flags().BoolP("watch", "w", false, "Watch for changes and re-run tests")
-	runCmd.Flags().Bool("json", false, "Output results in JSON format")
-	runCmd.Flags().BoolP("pretty", "p", false, "Human-readable output with colors and emojis")
+	listCmd.Flags().Bool("json", false, "Output the list as JSON")
+	listCmd.Flags().BoolP("pretty", "p", false, "Human-readable output grouped by file")
 
-	initCmd := &cobra.Command{
-		Use:   "init",
-		Short: "Initialize Vyb in current directory",
+	lintCmd := &cobra.Command{
+		Use:   "lint [pattern]",
+		Short: "Check test files for mistakes without running them",
+		Long: "Check the test files matching the pattern (default: **/*.test.vyb) for parse errors, unknown\n" +
+			"keys, steps without 'expect:', variables used before they are set, duplicate test names and\n" +
+			"calls to functions the modules don't seem to export. Exits with 1 when there are errors.",
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := initializeProject(); err != nil {
+			pattern := "**/*.test.vyb"
+			if len(args) > 0 {
+				pattern = args[0]
+			}
+
+			format := runner.OutputPretty
+			if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+				format = runner.OutputJSON
+			}
+
+			if err := runner.Lint(pattern, format, os.Stdout); err != nil {
+				os.Exit(1) // The diagnostics are already printed
+			}
+		},
+	}
+	lintCmd.Flags().Bool("json", false, "Output diagnostics as JSON")
+
+	fmtCmd := &cobra.Command{
+		Use:   "fmt [paths...]",
+		Short: "Rewrite test files in the canonical layout",
+		Long: "Rewrite the test files matching the paths (default: **/*.test.vyb) in the canonical layout:\n" +
+			"keys in the order confidence, tags, given, when, then, consistent quoting, and legacy 'test:'\n" +
+			"files migrated to name-as-key. Comments are kept. With --check, files are only listed and\n" +
+			"the command exits with 1 when any is not formatted.",
+		Run: func(cmd *cobra.Command, args []string) {
+			patterns := args
+			if len(patterns) == 0 {
+				patterns = []string{"**/*.test.vyb"}
+			}
+			check, _ := cmd.Flags().GetBool("check")
+
+			if err := runner.Format(patterns, check, os.Stdout); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
-			fmt.Println("✅ Vyb initialized!")
-			fmt.Println("\nNext steps:")
-			fmt.Println("  1. Create a test file: example.test.vyb")
-			fmt.Println("  2. Run tests: vyb run")
 		},
 	}
+	fmtCmd.Flags().Bool("check", false, "List files that are not formatted instead of rewriting them")
 
-	rootCmd.AddCommand(runCmd, initCmd)
+	rootCmd.AddCommand(runCmd, initCmd, resolveCmd, calibrationCmd, listCmd, lintCmd, fmtCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
+// newRunCommand returns the run command with its flags
+func newRunCommand() *cobra.Command {
+	runCmd := &cobra.Command{
+		Use:   "run [pattern]",
+		Short: "Run tests",
+		Long:  "Run all tests matching the pattern (default: **/*.test.vyb)",
+		Run: func(cmd *cobra.Command, args []string) {
+			pattern := "**/*.test.vyb"
+			if len(args) > 0 {
+				pattern = args[0]
+			}
+
+			opts, err := runOptions(cmd)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err := runner.Run(pattern, opts); err != nil {
+				// Failures and gates are in the report; other errors are only known here
+				var failedErr *runner.TestsFailedError
+				var gateErr *runner.ConfidenceGateError
+				isGate := errors.As(err, &gateErr)
+				if opts.Pretty() || (!isGate && !errors.As(err, &failedErr)) {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				}
+				if isGate {
+					os.Exit(runner.ExitConfidenceGate)
+				}
+				os.Exit(1)
+			}
+		},
+	}
+	runCmd.Flags().BoolP("watch", "w", false, "Watch for changes and re-run tests")
+	runCmd.Flags().Bool("json", false, "Output results in JSON format")
+	runCmd.Flags().BoolP("pretty", "p", false, "Human-readable output with colors and emojis")
+	runCmd.Flags().StringArray("reporter", nil, "Reporter as name or name=path (repeatable): pretty, json, suggest, junit, tap, ndjson, github, html or sarif")
+	runCmd.Flags().StringP("output", "o", "", "Write the report to a file instead of stdout")
+	runCmd.Flags().String("changed", "", "Only run tests affected by files changed since a git ref (--changed=<ref>, default HEAD)")
+	runCmd.Flags().Lookup("changed").NoOptDefVal = "HEAD"
+	runCmd.Flags().Bool("bail", false, "Stop after the first failing test")
+	runCmd.Flags().Int("max-failures", 0, "Stop after N failing tests (remaining tests are reported as not run)")
+	runCmd.Flags().Int("retries", 0, "Retry failing tests up to N times; tests passing on retry are reported as flaky")
+	runCmd.Flags().String("shard", "", "Run only shard i of N of the test files (e.g. 2/4), round-robin unless --durations is given")
+	runCmd.Flags().String("durations", "", "Shared per-file durations file: --shard balances by it and the run records into it")
+	runCmd.Flags().Bool("shuffle", false, "Run files and tests in random order (the seed is printed)")
+	runCmd.Flags().Int64("seed", 0, "Non-zero seed for --shuffle to reproduce a previous order (implies --shuffle)")
+	runCmd.Flags().Bool("compact", false, "Suggest output with failures only: deduplicated, truncated, most confident first")
+	runCmd.Flags().Int("max-tokens", 0, "Fit suggest output into roughly N tokens (implies --compact)")
+	runCmd.Flags().Float64("min-avg-confidence", 0, "Fail the run (exit code 3) when the average confidence of the tests is lower (0 turns off the config's min_average)")
+	runCmd.Flags().StringArray("require-confidence-for", nil, "Fail the run (exit code 3) when a test in matching files has lower confidence: <glob>=<confidence> (repeatable)")
+
+	return runCmd
+}
+
+// runOptions builds the runner options from the run command's flags
+func runOptions(cmd *cobra.Command) (runner.Options, error) {
+	watch, _ := cmd.Flags().GetBool("watch")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	prettyOutput, _ := cmd.Flags().GetBool("pretty")
+
+	// Default is YAML output (AI-native)
+	format := runner.OutputSuggest
+	if prettyOutput {
+		format = runner.OutputPretty
+	}
+	if jsonOutput {
+		format = runner.OutputJSON
+	}
+
+	var reporters []runner.ReporterSpec
+	reporterValues, _ := cmd.Flags().GetStringArray("reporter")
+	for _, value := range reporterValues {
+		spec, err := runner.ParseReporterSpec(value)
+		if err != nil {
+			return runner.Options{}, err
+		}
+		reporters = append(reporters, spec)
+	}
+	if len(reporters) == 0 {
+		reporters = append(reporters, runner.ReporterSpec{Format: format})
+	}
+
+	// --output applies to a single reporter without its own destination
+	if output, _ := cmd.Flags().GetString("output"); output != "" {
+		if len(reporters) > 1 || reporters[0].Output != "" {
+			return runner.Options{}, fmt.Errorf("--output only works with a single reporter; use --reporter name=path instead")
+		}
+		reporters[0].Output = output
+	}
+
+	opts := runner.Options{
+		Reporters: reporters,
+		Watch:     watch,
+	}
+	opts.MaxFailures, _ = cmd.Flags().GetInt("max-failures")
+	if opts.MaxFailures < 0 {
+		return runner.Options{}, fmt.Errorf("--max-failures must not be negative")
+	}
+	if bail, _ := cmd.Flags().GetBool("bail"); bail {
+		opts.MaxFailures = 1
+	}
+	opts.Retries, _ = cmd.Flags().GetInt("retries")
+	if opts.Retries < 0 {
+		return runner.Options{}, fmt.Errorf("--retries must not be negative")
+	}
+	if shard, _ := cmd.Flags().GetString("shard"); shard != "" {
+		index, total, err := runner.ParseShard(shard)
+		if err != nil {
+			return runner.Options{}, err
+		}
+		opts.ShardIndex, opts.ShardTotal = index, total
+	}
+	opts.Durations, _ = cmd.Flags().GetString("durations")
+	if shuffle, _ := cmd.Flags().GetBool("shuffle"); shuffle {
+		opts.Shuffle = true
+	}
+	if cmd.Flags().Changed("seed") {
+		opts.Shuffle = true
+		opts.Seed, _ = cmd.Flags().GetInt64("seed")
+		if opts.Seed == 0 {
+			// 0 means "pick a new seed", so it could never reproduce an order
+			return runner.Options{}, fmt.Errorf("--seed must not be 0")
+		}
+	}
+	opts.Compact, _ = cmd.Flags().GetBool("compact")
+	opts.MaxTokens, _ = cmd.Flags().GetInt("max-tokens")
+	if cmd.Flags().Changed("changed") {
+		opts.Changed = true
+		opts.ChangedRef, _ = cmd.Flags().GetString("changed")
+	}
+
+	if cmd.Flags().Changed("min-avg-confidence") {
+		minAvg, _ := cmd.Flags().GetFloat64("min-avg-confidence")
+		if minAvg < 0 || minAvg > 1 {
+			return runner.Options{}, fmt.Errorf("--min-avg-confidence must be between 0 and 1")
+		}
+		opts.MinAvgConfidence = &minAvg
+	}
+	requireValues, _ := cmd.Flags().GetStringArray("require-confidence-for")
+	for _, value := range requireValues {
+		requirement, err := runner.ParseConfidenceRequirement(value)
+		if err != nil {
+			return runner.Options{}, err
+		}
+		opts.RequireConfidence = append(opts.RequireConfidence, requirement)
+	}
+
+	return opts, nil
+}
+
 func initializeProject() error {
 	// Create vyb.config.json
 	config := `{
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunOptionsRejectsInvalidFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--retries", "-1"}, "--retries"},
+		{[]string{"--max-failures", "-1"}, "--max-failures"},
+		{[]string{"--seed", "0"}, "--seed"},
+		{[]string{"--min-avg-confidence", "1.5"}, "--min-avg-confidence"},
+		{[]string{"--reporter", "junit=a.xml", "--output", "b.xml"}, "--output"},
+	}
+	for _, tt := range tests {
+		cmd := newRunCommand()
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatalf("ParseFlags(%v) failed: %v", tt.args, err)
+		}
+		if _, err := runOptions(cmd); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("runOptions(%v) error = %v, want one about %s", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestRunOptionsMinAvgConfidence(t *testing.T) {
+	cmd := newRunCommand()
+	if opts, err := runOptions(cmd); err != nil || opts.MinAvgConfidence != nil {
+		t.Errorf("MinAvgConfidence = %v, err = %v, want nil without the flag", opts.MinAvgConfidence, err)
+	}
+
+	// 0 is an explicit override of the config's min_average
+	if err := cmd.ParseFlags([]string{"--min-avg-confidence", "0"}); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := runOptions(cmd)
+	if err != nil || opts.MinAvgConfidence == nil || *opts.MinAvgConfidence != 0 {
+		t.Errorf("MinAvgConfidence = %v, err = %v, want 0", opts.MinAvgConfidence, err)
+	}
+}
+
+func TestRunOptionsRetries(t *testing.T) {
+	cmd := newRunCommand()
+	if err := cmd.ParseFlags([]string{"--retries", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := runOptions(cmd)
+	if err != nil || opts.Retries != 2 {
+		t.Errorf("Retries = %d, err = %v, want 2", opts.Retries, err)
+	}
+}
@@ -10,10 +10,13 @@ type TestFile struct {
 type Test struct {
 	Name       string                 `yaml:"name"`
 	Confidence float64                `yaml:"confidence"`
+	Tags       []string               `yaml:"tags,omitempty"` // Labels for selecting and grouping tests
 	Given      map[string]interface{} `yaml:"given"`
 	When       []string               `yaml:"when"`
 	Then       []string               `yaml:"then"`
+	Retries    *int                   `yaml:"retries,omitempty"` // Extra attempts before the test counts as failed (nil = use --retries)
 	LLMVerify  *LLMVerification       `yaml:"llm_verify,omitempty"`
+	Line       int                    `yaml:"-"` // 1-based line of the test in its file (0 if unknown)
 }
 
 // LLMVerification represents natural language verification
@@ -25,11 +28,21 @@ type LLMVerification struct {
 
 // TestResult represents the result of running a test
 type TestResult struct {
-	Name       string
-	Passed     bool
-	Error      string
-	Duration   int64 // nanoseconds
-	Confidence float64
-	Actual     interface{} // Actual value when expectation fails
-	Expected   interface{} // Expected value when expectation fails
+	Name        string
+	Passed      bool
+	Error       string
+	Cause       string   // The underlying error without the step, shared by identical failures
+	Code        string   // Stable error code, e.g. VYB_ASSERT_MISMATCH
+	Suggestions []string // Names that were probably meant, for undefined variables and unknown functions
+	Step        string   // Phase of the failed step: "when" or "then"
+	StepIndex   int      // 0-based position of the failed step within its phase
+	Duration    int64    // nanoseconds
+	Confidence  float64
+	Actual      interface{} // Actual value when expectation fails
+	Expected    interface{} // Expected value when expectation fails
+	Operator    string      // Operator of the failed expectation (e.g. "==", "contains")
+	Stack       string      // Stack trace of an error thrown by an external function
+	Function    string      // External function whose call failed (empty for other failures)
+	Attempts    int         // Number of times the test was run (>1 when retried)
+	Flaky       bool        // Passed only after retrying
 }
@@ -10,8 +10,28 @@ import (
 
 // Config represents vyb.config.yaml
 type Config struct {
-	Runtime string   `yaml:"runtime"` // "node", "python", "go", "lua", etc.
-	Modules []string `yaml:"modules"` // Paths to modules
+	Runtime    string           `yaml:"runtime"`    // "node", "python", "go", "lua", etc.
+	Modules    []string         `yaml:"modules"`    // Paths to modules
+	Hints      []HintRule       `yaml:"hints"`      // Project-specific hints for failed tests
+	Confidence ConfidenceConfig `yaml:"confidence"` // Confidence gates for CI
+	Durations  string           `yaml:"durations"`  // Shared file of per-file durations for balancing --shard
+}
+
+// ConfidenceConfig sets the confidence a run must reach, or it fails
+type ConfidenceConfig struct {
+	MinAverage float64            `yaml:"min_average"` // Minimum average confidence of the tests that ran
+	Require    map[string]float64 `yaml:"require"`     // Minimum confidence of every test in files matching a glob
+}
+
+// HintRule adds a custom hint to failed tests that match all of its conditions.
+// Empty conditions match anything; a rule needs at least one condition.
+type HintRule struct {
+	Hint     string `yaml:"hint"`     // Text to show; may use {actual}, {expected}, {function}, {test} and {file}
+	Code     string `yaml:"code"`     // Error code, e.g. VYB_ASSERT_MISMATCH
+	Function string `yaml:"function"` // Glob over the failed external call, or over the functions the test calls
+	File     string `yaml:"file"`     // Glob over the test file path
+	Actual   string `yaml:"actual"`   // Predicate on the actual value, e.g. "null" or "> 100"
+	Expected string `yaml:"expected"` // Predicate on the expected value
 }
 
 // LoadConfig reads vyb.config.yaml from the current directory or specified path
@@ -20,11 +20,14 @@ func Parse(filename string) (*TestFile, error) {
 // ParseBytes parses test data from bytes
 func ParseBytes(filename string, data []byte) (*TestFile, error) {
 	// Try new format first: test name as key
-	var testsMap map[string]TestConfig
-	if err := yaml.Unmarshal(data, &testsMap); err == nil && len(testsMap) > 0 {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err == nil && len(doc.Content) > 0 {
+		root := doc.Content[0]
 		// Check if this looks like the new format (no "test" key, has test-like keys)
-		if _, hasOldFormat := testsMap["test"]; !hasOldFormat {
-			return parseNewFormat(filename, testsMap)
+		if root.Kind == yaml.MappingNode && len(root.Content) > 0 && !hasKey(root, "test") {
+			if entries, ok := decodeTestConfigs(root); ok {
+				return parseNewFormat(filename, entries)
+			}
 		}
 	}
 
@@ -35,16 +38,56 @@ func ParseBytes(filename string, data []byte) (*TestFile, error) {
 // TestConfig represents a test configuration (without the name, which is the key)
 type TestConfig struct {
 	Confidence float64                `yaml:"confidence"`
+	Tags       []string               `yaml:"tags"`
 	Given      map[string]interface{} `yaml:"given"`
 	When       []string               `yaml:"when"`
 	Then       []string               `yaml:"then"`
+	Retries    *int                   `yaml:"retries"`
+}
+
+// testEntry is a named test configuration in document order
+type testEntry struct {
+	Name   string
+	Line   int
+	Config TestConfig
+}
+
+// hasKey reports whether a mapping node contains the given key
+func hasKey(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeTestConfigs decodes every key/value pair of a mapping node, preserving file order
+func decodeTestConfigs(mapping *yaml.Node) ([]testEntry, bool) {
+	var entries []testEntry
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		var config TestConfig
+		if err := mapping.Content[i+1].Decode(&config); err != nil {
+			return nil, false
+		}
+		entries = append(entries, testEntry{Name: mapping.Content[i].Value, Line: mapping.Content[i].Line, Config: config})
+	}
+	return entries, true
 }
 
 // parseNewFormat parses the new format: test name as key
-func parseNewFormat(filename string, testsMap map[string]TestConfig) (*TestFile, error) {
+func parseNewFormat(filename string, entries []testEntry) (*TestFile, error) {
 	var tests []Test
 
-	for name, config := range testsMap {
+	defined := make(map[string]int) // Name -> line of its first definition
+	for _, entry := range entries {
+		name, config := entry.Name, entry.Config
+
+		if first, ok := defined[name]; ok {
+			return nil, fmt.Errorf("duplicate test name '%s' (first defined on line %d)", name, first)
+		}
+		defined[name] = entry.Line
+
 		// Skip keys that don't look like tests (e.g., metadata)
 		if len(config.When) == 0 && len(config.Then) == 0 {
 			continue
@@ -53,9 +96,12 @@ func parseNewFormat(filename string, testsMap map[string]TestConfig) (*TestFile,
 		test := Test{
 			Name:       name,
 			Confidence: config.Confidence,
+			Tags:       config.Tags,
 			Given:      config.Given,
 			When:       config.When,
 			Then:       config.Then,
+			Retries:    config.Retries,
+			Line:       entry.Line,
 		}
 
 		// Default confidence
@@ -73,6 +119,9 @@ func parseNewFormat(filename string, testsMap map[string]TestConfig) (*TestFile,
 		if len(test.Then) == 0 {
 			return nil, fmt.Errorf("test '%s' must have 'then' assertions", test.Name)
 		}
+		if test.Retries != nil && *test.Retries < 0 {
+			return nil, fmt.Errorf("test '%s' has negative retries", test.Name)
+		}
 
 		tests = append(tests, test)
 	}
@@ -101,12 +150,26 @@ func parseOldFormat(filename string, data []byte) (*TestFile, error) {
 	if testData.Test.Name == "" {
 		return nil, fmt.Errorf("test must have a name")
 	}
+	if testData.Test.Retries != nil && *testData.Test.Retries < 0 {
+		return nil, fmt.Errorf("test '%s' has negative retries", testData.Test.Name)
+	}
 
 	// Default confidence if not specified
 	if testData.Test.Confidence == 0 {
 		testData.Test.Confidence = 1.0
 	}
 
+	// Position of the "test:" key, for annotations
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err == nil && len(doc.Content) > 0 {
+		root := doc.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "test" {
+				testData.Test.Line = root.Content[i].Line
+			}
+		}
+	}
+
 	return &TestFile{
 		Filename: filename,
 		Tests:    []Test{testData.Test},
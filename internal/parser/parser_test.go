@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -216,3 +217,171 @@ func TestParseEmptyFile(t *testing.T) {
 		t.Error("Expected error for empty file, got nil")
 	}
 }
+
+func TestParseNewFormatPreservesOrder(t *testing.T) {
+	yaml := `
+"zeta test":
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+"alpha test":
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+"middle test":
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+`
+
+	testFile, err := ParseBytes("test.vyb", []byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := []string{"zeta test", "alpha test", "middle test"}
+	if len(testFile.Tests) != len(expected) {
+		t.Fatalf("Expected %d tests, got %d", len(expected), len(testFile.Tests))
+	}
+	for i, name := range expected {
+		if testFile.Tests[i].Name != name {
+			t.Errorf("Expected test %d to be '%s', got '%s'", i, name, testFile.Tests[i].Name)
+		}
+	}
+}
+
+func TestParseRecordsTestLines(t *testing.T) {
+	yaml := `# math tests
+"adds":
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+
+"subtracts":
+  when:
+    - "x = sub(3, 2)"
+  then:
+    - "expect: x == 1"
+`
+
+	testFile, err := ParseBytes("test.vyb", []byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	for i, line := range []int{2, 8} {
+		if testFile.Tests[i].Line != line {
+			t.Errorf("Expected test %d on line %d, got %d", i, line, testFile.Tests[i].Line)
+		}
+	}
+
+	old := `test:
+  name: "adds"
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+`
+	testFile, err = ParseBytes("old.vyb", []byte(old))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if testFile.Tests[0].Line != 1 {
+		t.Errorf("Expected old-format test on line 1, got %d", testFile.Tests[0].Line)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	yaml := `"adds":
+  tags: [math, fast]
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+`
+
+	testFile, err := ParseBytes("test.vyb", []byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if tags := testFile.Tests[0].Tags; len(tags) != 2 || tags[0] != "math" || tags[1] != "fast" {
+		t.Errorf("Expected tags [math fast], got %v", tags)
+	}
+
+	old := `test:
+  name: "adds"
+  tags: [math]
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+`
+	testFile, err = ParseBytes("old.vyb", []byte(old))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if tags := testFile.Tests[0].Tags; len(tags) != 1 || tags[0] != "math" {
+		t.Errorf("Expected old-format tags [math], got %v", tags)
+	}
+}
+
+func TestParseDuplicateTestNames(t *testing.T) {
+	yaml := `"adds":
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+"adds":
+  when:
+    - "x = add(2, 2)"
+  then:
+    - "expect: x == 4"
+`
+
+	_, err := ParseBytes("test.vyb", []byte(yaml))
+	if err == nil {
+		t.Fatal("Expected error for duplicate test names")
+	}
+	if !strings.Contains(err.Error(), "duplicate test name 'adds' (first defined on line 1)") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestParseRetries(t *testing.T) {
+	yaml := `"opts out":
+  retries: 0
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+"inherits":
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+`
+
+	testFile, err := ParseBytes("test.vyb", []byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if retries := testFile.Tests[0].Retries; retries == nil || *retries != 0 {
+		t.Errorf("Expected retries: 0 to be kept, got %v", retries)
+	}
+	if retries := testFile.Tests[1].Retries; retries != nil {
+		t.Errorf("Expected no retries when not set, got %d", *retries)
+	}
+
+	for name, data := range map[string]string{
+		"new format": "\"adds\":\n  retries: -1\n  when: [\"x = add(1, 2)\"]\n  then: [\"expect: x == 3\"]\n",
+		"old format": "test:\n  name: adds\n  retries: -1\n  when: [\"x = add(1, 2)\"]\n  then: [\"expect: x == 3\"]\n",
+	} {
+		if _, err := ParseBytes("test.vyb", []byte(data)); err == nil || !strings.Contains(err.Error(), "negative retries") {
+			t.Errorf("%s: expected negative retries error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BridgeError is an error thrown by an external function. Stack holds the runtime's
+// stack trace when the bridge could capture one.
+type BridgeError struct {
+	Code    ErrorCode
+	Message string
+	Stack   string
+}
+
+func (e *BridgeError) Error() string {
+	return "external function error: " + e.Message
+}
+
+// ErrorCode returns the error's code
+func (e *BridgeError) ErrorCode() ErrorCode {
+	return e.Code
+}
+
+// bridgeResponse is the JSON line a bridge script prints for every call
+type bridgeResponse struct {
+	Result interface{} `json:"result"`
+	Error  string      `json:"error"`
+	Stack  string      `json:"stack"`
+	Kind   string      `json:"kind"` // "not_found", "unserializable" or empty when the function threw
+}
+
+// decodeBridgeResponse finds the response in a bridge script's output. Scripts exit
+// non-zero when the called function throws, so the output is checked even when the
+// process failed; ok is false if the runtime crashed before the script could answer.
+// The response is the last line, anything printed by the code under test comes before it.
+func decodeBridgeResponse(output []byte) (response bridgeResponse, ok bool) {
+	lines := bytes.Split(bytes.TrimSpace(output), []byte("\n"))
+	last := bytes.TrimSpace(lines[len(lines)-1])
+	if err := json.Unmarshal(last, &response); err != nil {
+		return bridgeResponse{}, false
+	}
+	return response, true
+}
+
+// value returns the call's result, or a *BridgeError when the function threw
+func (r bridgeResponse) value() (interface{}, error) {
+	if r.Error == "" {
+		return r.Result, nil
+	}
+
+	code := CodeExternalError
+	switch r.Kind {
+	case "not_found":
+		code = CodeUnknownFunc
+	case "unserializable":
+		code = CodeUnserializable
+	}
+	return nil, &BridgeError{Code: code, Message: r.Error, Stack: r.Stack}
+}
+
+// moduleNotFoundPatterns are what the runtimes print when a module can't be loaded
+var moduleNotFoundPatterns = []string{
+	"Cannot find module",  // Node
+	"ModuleNotFoundError", // Python
+	"module '",            // Lua: module 'x' not found
+}
+
+// bridgeCrash describes a bridge process that exited without a response
+func bridgeCrash(err error, output []byte, format string, args ...interface{}) error {
+	code := CodeBridgeCrash
+	for _, pattern := range moduleNotFoundPatterns {
+		if strings.Contains(string(output), pattern) {
+			code = CodeModuleNotFound
+			break
+		}
+	}
+	return wrapError(code, err, format, args...)
+}
+
+// functionList caches the names of the functions a bridge's modules export, which
+// are only needed (and fetched) when a test calls an unknown function
+type functionList struct {
+	once  sync.Once
+	names []string
+	err   error
+}
+
+// get asks the bridge script for its function list once and remembers the answer
+func (l *functionList) get(send func(request map[string]interface{}) (interface{}, error)) ([]string, error) {
+	l.once.Do(func() {
+		value, err := send(map[string]interface{}{"list": true})
+		if err != nil {
+			l.err = err
+			return
+		}
+
+		// Lua's fallback JSON encoder writes lists as objects keyed by index
+		var items []interface{}
+		switch list := value.(type) {
+		case []interface{}:
+			items = list
+		case map[string]interface{}:
+			for _, item := range list {
+				items = append(items, item)
+			}
+		}
+		for _, item := range items {
+			if name, ok := item.(string); ok {
+				l.names = append(l.names, name)
+			}
+		}
+		sort.Strings(l.names)
+	})
+	return l.names, l.err
+}
+
+// processTracker tracks the subprocess currently serving a bridge call so that it can
+// be terminated when a run is stopped early. Bridges embed it to become io.Closers.
+type processTracker struct {
+	mu     sync.Mutex
+	active *exec.Cmd
+	closed bool
+}
+
+// run executes cmd like CombinedOutput while tracking it as the active process. The
+// process is started under the lock so that Close either sees it or prevents it.
+func (p *processTracker) run(cmd *exec.Cmd) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("bridge is closed")
+	}
+	if err := cmd.Start(); err != nil {
+		p.mu.Unlock()
+		return nil, err
+	}
+	p.active = cmd
+	p.mu.Unlock()
+
+	err := cmd.Wait()
+
+	p.mu.Lock()
+	p.active = nil
+	p.mu.Unlock()
+
+	return output.Bytes(), err
+}
+
+// Close kills any in-flight bridge process and rejects further calls
+func (p *processTracker) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.active != nil && p.active.Process != nil {
+		if err := p.active.Process.Kill(); err != nil {
+			return fmt.Errorf("failed to stop bridge process: %w", err)
+		}
+	}
+	return nil
+}
+
+// closeBridge releases a bridge's worker processes, if it has any
+func closeBridge(bridge Bridge) {
+	if closer, ok := bridge.(io.Closer); ok {
+		closer.Close()
+	}
+}
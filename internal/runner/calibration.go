@@ -0,0 +1,406 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// historyFile records failed tests and how their failures were resolved, one JSON
+// object per line, so stated confidence can be checked against real outcomes
+const historyFile = ".vyb/history"
+
+const (
+	minCalibrationSamples = 5    // Resolved failures needed before history adjusts a confidence note
+	calibrationTolerance  = 0.25 // How far the code bug rate may be from the stated confidence
+)
+
+// Resolutions recorded by vyb resolve
+const (
+	ResolutionCodeBug = "code_bug" // The implementation was wrong
+	ResolutionTestBug = "test_bug" // The test was wrong
+)
+
+// historyEntry is one line of the history file: a failed test, or the resolution of
+// its latest failure
+type historyEntry struct {
+	Time       time.Time `json:"time"`
+	Kind       string    `json:"kind"` // "failure" or "resolution"
+	File       string    `json:"file"`
+	Test       string    `json:"test"`
+	Line       int       `json:"line,omitempty"`
+	Confidence float64   `json:"confidence,omitempty"`
+	Code       string    `json:"code,omitempty"`
+	Resolution string    `json:"resolution,omitempty"`
+}
+
+// resolvedFailure is a failure together with its resolution
+type resolvedFailure struct {
+	historyEntry
+	CodeBug bool
+}
+
+// newFailureEntry records a failed test for the history
+func newFailureEntry(now time.Time, file string, test *parser.Test, result parser.TestResult) historyEntry {
+	return historyEntry{
+		Time:       now,
+		Kind:       "failure",
+		File:       filepath.ToSlash(filepath.Clean(file)),
+		Test:       result.Name,
+		Line:       test.Line,
+		Confidence: result.Confidence,
+		Code:       result.Code,
+	}
+}
+
+// loadHistory reads the history recorded in dir (nil if there is none). Malformed
+// lines, e.g. from an interrupted write or a bad merge, are skipped and counted.
+func loadHistory(dir string) (entries []historyEntry, skipped int, err error) {
+	file, err := os.Open(filepath.Join(dir, historyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry historyEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, skipped, scanner.Err()
+}
+
+// appendHistory adds entries to the history file
+func appendHistory(dir string, entries []historyEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	path := filepath.Join(dir, historyFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// replayHistory pairs each resolution with the latest failure of its test before it.
+// Earlier unresolved failures of the same test are taken to be the same incident and
+// dropped. It also returns the failures still waiting for a resolution, by file and test.
+func replayHistory(entries []historyEntry) ([]resolvedFailure, map[[2]string]historyEntry) {
+	var resolved []resolvedFailure
+	pending := make(map[[2]string]historyEntry)
+
+	for _, entry := range entries {
+		key := [2]string{entry.File, entry.Test}
+		switch entry.Kind {
+		case "failure":
+			pending[key] = entry
+		case "resolution":
+			if failure, ok := pending[key]; ok {
+				resolved = append(resolved, resolvedFailure{failure, entry.Resolution == ResolutionCodeBug})
+				delete(pending, key)
+			}
+		}
+	}
+	return resolved, pending
+}
+
+// Resolve records whether the latest failure of a test was a bug in the code or in the
+// test. file is only needed when tests in several files share the name.
+func Resolve(testName, file, resolution string) (string, error) {
+	if resolution != ResolutionCodeBug && resolution != ResolutionTestBug {
+		return "", fmt.Errorf("unknown resolution %q", resolution)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	entries, _, err := loadHistory(cwd)
+	if err != nil {
+		return "", fmt.Errorf("failed to read history: %w", err)
+	}
+
+	_, pending := replayHistory(entries)
+	var files []string
+	for key := range pending {
+		if key[1] == testName && (file == "" || key[0] == filepath.ToSlash(filepath.Clean(file))) {
+			files = append(files, key[0])
+		}
+	}
+	sort.Strings(files)
+
+	switch len(files) {
+	case 0:
+		return "", fmt.Errorf("no unresolved failure of %q in %s (run the tests first)", testName, historyFile)
+	case 1:
+	default:
+		return "", fmt.Errorf("%q failed in several files (%s); choose one with --file", testName, strings.Join(files, ", "))
+	}
+
+	entry := historyEntry{Time: time.Now().UTC(), Kind: "resolution", File: files[0], Test: testName, Resolution: resolution}
+	if err := appendHistory(cwd, []historyEntry{entry}); err != nil {
+		return "", fmt.Errorf("failed to record resolution: %w", err)
+	}
+	return files[0], nil
+}
+
+// calibrationStats counts resolved failures of tests with similar stated confidence
+type calibrationStats struct {
+	Resolved      int
+	CodeBugs      int
+	ConfidenceSum float64
+	Scope         string // What the failures have in common, e.g. "in this file"
+}
+
+func (s *calibrationStats) add(failure resolvedFailure) {
+	s.Resolved++
+	s.ConfidenceSum += failure.Confidence
+	if failure.CodeBug {
+		s.CodeBugs++
+	}
+}
+
+// codeBugRate is the share of failures that were bugs in the code: how confident the
+// tests should have been
+func (s *calibrationStats) codeBugRate() float64 {
+	return float64(s.CodeBugs) / float64(s.Resolved)
+}
+
+// averageConfidence is the mean confidence the tests stated
+func (s *calibrationStats) averageConfidence() float64 {
+	return s.ConfidenceSum / float64(s.Resolved)
+}
+
+// confidenceBands are the lower bounds of the confidence levels getConfidenceNote
+// distinguishes
+var confidenceBands = []float64{0.95, 0.85, 0.70, 0.50, 0}
+
+// confidenceBand returns the index of the band a confidence falls in
+func confidenceBand(confidence float64) int {
+	for i, bound := range confidenceBands {
+		if confidence >= bound {
+			return i
+		}
+	}
+	return len(confidenceBands) - 1
+}
+
+// bandLabel names a confidence band, e.g. "0.85-0.95"
+func bandLabel(band int) string {
+	if band == 0 {
+		return fmt.Sprintf("%.2f+", confidenceBands[0])
+	}
+	return fmt.Sprintf("%.2f-%.2f", confidenceBands[band], confidenceBands[band-1])
+}
+
+// calibration holds resolved failures by file and confidence band
+type calibration struct {
+	byFile  map[string]map[int]*calibrationStats
+	overall map[int]*calibrationStats
+}
+
+// newCalibration groups resolved failures for looking up confidence notes
+func newCalibration(resolved []resolvedFailure) *calibration {
+	c := &calibration{
+		byFile:  make(map[string]map[int]*calibrationStats),
+		overall: make(map[int]*calibrationStats),
+	}
+	for _, failure := range resolved {
+		band := confidenceBand(failure.Confidence)
+		if c.byFile[failure.File] == nil {
+			c.byFile[failure.File] = make(map[int]*calibrationStats)
+		}
+		if c.byFile[failure.File][band] == nil {
+			c.byFile[failure.File][band] = &calibrationStats{Scope: "in this file"}
+		}
+		if c.overall[band] == nil {
+			c.overall[band] = &calibrationStats{Scope: "in this project"}
+		}
+		c.byFile[failure.File][band].add(failure)
+		c.overall[band].add(failure)
+	}
+	return c
+}
+
+// loadCalibration reads the resolved failures recorded in dir, and how many malformed
+// history lines were skipped
+func loadCalibration(dir string) (*calibration, int, error) {
+	entries, skipped, err := loadHistory(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	resolved, _ := replayHistory(entries)
+	return newCalibration(resolved), skipped, nil
+}
+
+// lookup returns what history says about failures of tests in file with a similar
+// confidence: from the file itself when it has enough resolved failures, otherwise from
+// the whole project (nil when neither has)
+func (c *calibration) lookup(file string, confidence float64) *calibrationStats {
+	if c == nil {
+		return nil
+	}
+	band := confidenceBand(confidence)
+	if stats := c.byFile[filepath.ToSlash(filepath.Clean(file))][band]; stats != nil && stats.Resolved >= minCalibrationSamples {
+		return stats
+	}
+	if stats := c.overall[band]; stats != nil && stats.Resolved >= minCalibrationSamples {
+		return stats
+	}
+	return nil
+}
+
+// testAuthors maps the lines of a file to the authors who last changed them, using
+// git blame (nil outside a git repository)
+func testAuthors(file string) map[int]string {
+	output, err := gitOutput("", "blame", "--line-porcelain", "--", file)
+	if err != nil {
+		return nil
+	}
+
+	authors := make(map[int]string)
+	line := 0
+	for _, text := range strings.Split(output, "\n") {
+		fields := strings.Fields(text)
+		switch {
+		case len(fields) >= 3 && len(fields[0]) >= 40 && !strings.HasPrefix(text, "\t"):
+			// Header: <sha> <original line> <final line> [<group size>]
+			line, _ = strconv.Atoi(fields[2])
+		case strings.HasPrefix(text, "author "):
+			authors[line] = strings.TrimPrefix(text, "author ")
+		}
+	}
+	return authors
+}
+
+// CalibrationReport writes how well stated confidence predicted real bugs, grouped by
+// "file", "author" or "confidence" (all three when by is empty)
+func CalibrationReport(out io.Writer, by string) error {
+	groupings := []string{"confidence", "file", "author"}
+	if by != "" {
+		if by != "confidence" && by != "file" && by != "author" {
+			return fmt.Errorf("unknown grouping %q (use file, author or confidence)", by)
+		}
+		groupings = []string{by}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	entries, skipped, err := loadHistory(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+	resolved, pending := replayHistory(entries)
+	if skipped > 0 {
+		fmt.Fprintf(out, "Skipped %d malformed line(s) in %s\n", skipped, historyFile)
+	}
+
+	fmt.Fprintf(out, "Confidence calibration: %d resolved failure(s), %d waiting for 'vyb resolve'\n", len(resolved), len(pending))
+	if len(resolved) == 0 {
+		fmt.Fprintln(out, "\nRecord why a test failed with 'vyb resolve <test> --code-bug' or '--test-bug' to build up history.")
+		return nil
+	}
+
+	authors := make(map[string]map[int]string)
+	for _, grouping := range groupings {
+		groups := make(map[string]*calibrationStats)
+		for _, failure := range resolved {
+			var key string
+			switch grouping {
+			case "confidence":
+				key = bandLabel(confidenceBand(failure.Confidence))
+			case "file":
+				key = failure.File
+			case "author":
+				if _, ok := authors[failure.File]; !ok {
+					authors[failure.File] = testAuthors(failure.File)
+				}
+				key = authors[failure.File][failure.Line]
+				if key == "" {
+					key = "unknown"
+				}
+			}
+			if groups[key] == nil {
+				groups[key] = &calibrationStats{}
+			}
+			groups[key].add(failure)
+		}
+		writeCalibrationTable(out, grouping, groups)
+	}
+	return nil
+}
+
+// writeCalibrationTable prints one grouping of the calibration report. The gap is the
+// code bug rate minus the stated confidence: negative means the tests were overconfident.
+func writeCalibrationTable(out io.Writer, grouping string, groups map[string]*calibrationStats) {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if grouping == "confidence" {
+		// Highest confidence first
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	}
+
+	fmt.Fprintf(out, "\nBy %s:\n", grouping)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  %s\tResolved\tStated\tCode bugs\tGap\tVerdict\n", capitalize(grouping))
+	for _, key := range keys {
+		stats := groups[key]
+		gap := stats.codeBugRate() - stats.averageConfidence()
+		fmt.Fprintf(w, "  %s\t%d\t%.2f\t%.0f%%\t%+.0f\t%s\n", key, stats.Resolved, stats.averageConfidence(), stats.codeBugRate()*100, gap*100, calibrationVerdict(stats))
+	}
+	w.Flush()
+}
+
+// calibrationVerdict says whether a group's confidence can be trusted
+func calibrationVerdict(stats *calibrationStats) string {
+	gap := stats.codeBugRate() - stats.averageConfidence()
+	switch {
+	case stats.Resolved < minCalibrationSamples:
+		return "(too few to judge)"
+	case gap < -calibrationTolerance:
+		return "overconfident"
+	case gap > calibrationTolerance:
+		return "underconfident"
+	default:
+		return "calibrated"
+	}
+}
@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func failureEntry(file, test string, confidence float64) historyEntry {
+	return historyEntry{Kind: "failure", File: file, Test: test, Confidence: confidence}
+}
+
+func resolutionEntry(file, test, resolution string) historyEntry {
+	return historyEntry{Kind: "resolution", File: file, Test: test, Resolution: resolution}
+}
+
+func TestReplayHistory(t *testing.T) {
+	entries := []historyEntry{
+		failureEntry("a.vyb", "adds", 0.9),
+		failureEntry("a.vyb", "adds", 0.95), // Same incident, latest confidence wins
+		failureEntry("b.vyb", "adds", 0.8),
+		resolutionEntry("a.vyb", "adds", ResolutionTestBug),
+		resolutionEntry("a.vyb", "adds", ResolutionCodeBug), // Nothing left to resolve
+		failureEntry("a.vyb", "subtracts", 0.99),
+		resolutionEntry("a.vyb", "subtracts", ResolutionCodeBug),
+	}
+
+	resolved, pending := replayHistory(entries)
+	if len(resolved) != 2 {
+		t.Fatalf("resolved = %+v", resolved)
+	}
+	if resolved[0].Confidence != 0.95 || resolved[0].CodeBug || !resolved[1].CodeBug {
+		t.Errorf("resolved = %+v", resolved)
+	}
+	if _, ok := pending[[2]string{"b.vyb", "adds"}]; !ok || len(pending) != 1 {
+		t.Errorf("pending = %v", pending)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	now := time.Now()
+	appendHistory(dir, []historyEntry{
+		failureEntry("a.vyb", "adds", 0.9),
+		failureEntry("b.vyb", "adds", 0.9),
+		failureEntry("b.vyb", "subtracts", 0.9),
+	})
+
+	if file, err := Resolve("subtracts", "", ResolutionCodeBug); err != nil || file != "b.vyb" {
+		t.Errorf("Resolve = %q, %v", file, err)
+	}
+	if _, err := Resolve("subtracts", "", ResolutionCodeBug); err == nil {
+		t.Error("Expected error resolving a test twice")
+	}
+	if _, err := Resolve("adds", "", ResolutionTestBug); err == nil || !strings.Contains(err.Error(), "--file") {
+		t.Errorf("Expected ambiguity error, got %v", err)
+	}
+	if file, err := Resolve("adds", "./a.vyb", ResolutionTestBug); err != nil || file != "a.vyb" {
+		t.Errorf("Resolve = %q, %v", file, err)
+	}
+
+	entries, _, err := loadHistory(dir)
+	if err != nil || len(entries) != 5 {
+		t.Fatalf("entries = %v, err = %v", entries, err)
+	}
+	if last := entries[4]; last.Kind != "resolution" || last.Resolution != ResolutionTestBug || last.Time.Before(now.Add(-time.Minute)) {
+		t.Errorf("last entry = %+v", last)
+	}
+}
+
+func TestCalibrationLookup(t *testing.T) {
+	var resolved []resolvedFailure
+	for i := 0; i < 5; i++ {
+		resolved = append(resolved, resolvedFailure{failureEntry("a.vyb", "t", 0.95), i == 0})
+	}
+	resolved = append(resolved, resolvedFailure{failureEntry("b.vyb", "t", 0.97), true})
+	c := newCalibration(resolved)
+
+	if stats := c.lookup("a.vyb", 0.99); stats == nil || stats.Scope != "in this file" || stats.Resolved != 5 {
+		t.Errorf("a.vyb stats = %+v", stats)
+	}
+	if stats := c.lookup("b.vyb", 0.99); stats == nil || stats.Scope != "in this project" || stats.Resolved != 6 {
+		t.Errorf("b.vyb stats = %+v", stats)
+	}
+	if stats := c.lookup("a.vyb", 0.5); stats != nil {
+		t.Errorf("Expected no stats for another band, got %+v", stats)
+	}
+	var none *calibration
+	if none.lookup("a.vyb", 0.99) != nil {
+		t.Error("Expected nil calibration to have no stats")
+	}
+
+	note := getConfidenceNote(0.99, c.lookup("a.vyb", 0.99))
+	if !strings.Contains(note, "1 of 5 past failures at this confidence in this file were code bugs (20%)") || !strings.Contains(note, "overconfident") {
+		t.Errorf("note = %q", note)
+	}
+	if note := getConfidenceNote(0.99, nil); strings.Contains(note, "History") {
+		t.Errorf("note without history = %q", note)
+	}
+}
+
+func TestCalibrationReport(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	var buf bytes.Buffer
+	if err := CalibrationReport(&buf, ""); err != nil || !strings.Contains(buf.String(), "vyb resolve") {
+		t.Errorf("empty report = %q, %v", buf.String(), err)
+	}
+
+	appendHistory(dir, []historyEntry{
+		failureEntry("a.vyb", "adds", 0.9),
+		resolutionEntry("a.vyb", "adds", ResolutionCodeBug),
+	})
+	buf.Reset()
+	if err := CalibrationReport(&buf, "file"); err != nil {
+		t.Fatalf("CalibrationReport failed: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "By file:") || !strings.Contains(out, "a.vyb") || strings.Contains(out, "By author:") {
+		t.Errorf("report = %q", out)
+	}
+	if err := CalibrationReport(&buf, "team"); err == nil {
+		t.Error("Expected error for unknown grouping")
+	}
+}
+
+func TestLoadHistorySkipsMalformedLines(t *testing.T) {
+	dir := t.TempDir()
+	appendHistory(dir, []historyEntry{failureEntry("a.vyb", "adds", 0.9)})
+
+	file, _ := os.OpenFile(filepath.Join(dir, historyFile), os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString("{\"kind\": \"failure\", \"file\": \"a.v\n<<<<<<< HEAD\n")
+	file.Close()
+	appendHistory(dir, []historyEntry{resolutionEntry("a.vyb", "adds", ResolutionCodeBug)})
+
+	entries, skipped, err := loadHistory(dir)
+	if err != nil || len(entries) != 2 || skipped != 2 {
+		t.Errorf("entries = %v, skipped = %d, err = %v", entries, skipped, err)
+	}
+}
+
+// noticeCollector records the notices it receives
+type noticeCollector struct {
+	baseReporter
+	notices []string
+}
+
+func (r *noticeCollector) Notice(level NoticeLevel, message string) {
+	r.notices = append(r.notices, message)
+}
+
+func TestRunFilesHistory(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.WriteFile("a.vyb", []byte("breaks:\n  when:\n    - x = add(1, 1)\n  then:\n    - \"expect: x == 3\"\n"), 0644)
+	run := func(opts Options) []string {
+		collector := &noticeCollector{}
+		opts.Reporters = []ReporterSpec{{Format: OutputJSON, Output: filepath.Join("out", "results.json")}}
+		opts.observers = []Reporter{collector}
+		if err := runFiles([]string{"a.vyb"}, opts); err == nil || !strings.Contains(err.Error(), "test(s) failed") {
+			t.Errorf("runFiles() error = %v, want failed tests", err)
+		}
+		return collector.notices
+	}
+
+	// Watch re-runs don't record failures
+	run(Options{Watch: true})
+	if entries, _, _ := loadHistory(dir); len(entries) != 0 {
+		t.Errorf("watch run recorded history: %v", entries)
+	}
+	run(Options{})
+	if entries, _, _ := loadHistory(dir); len(entries) != 1 || entries[0].Test != "breaks" {
+		t.Errorf("entries = %v, want the failure of breaks", entries)
+	}
+
+	// A history that can't be read is a warning, not a reason to skip the tests
+	os.Remove(historyFile)
+	os.Mkdir(historyFile, 0755)
+	notices := run(Options{Watch: true})
+	if len(notices) != 1 || !strings.Contains(notices[0], "confidence notes are not calibrated") {
+		t.Errorf("notices = %q", notices)
+	}
+}
@@ -0,0 +1,193 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// sourceExtensions lists file types that may affect external functions even when
+// they are not listed as modules (e.g. helpers imported by a module)
+var sourceExtensions = map[string]bool{
+	".ts": true, ".tsx": true, ".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".py": true, ".lua": true, ".go": true,
+}
+
+// gitChangedFiles returns absolute paths of files that differ from ref in the local
+// git repository, including untracked files
+func gitChangedFiles(ref string) ([]string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	root, err := gitOutput("", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root = strings.TrimSpace(root)
+
+	diff, err := gitOutput(root, "diff", "--name-only", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := gitOutput(root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(diff+"\n"+untracked, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		files = append(files, filepath.Join(root, filepath.FromSlash(line)))
+	}
+
+	return files, nil
+}
+
+// gitOutput runs a git command (optionally in dir) and returns its stdout
+func gitOutput(dir string, args ...string) (string, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+
+	return string(output), nil
+}
+
+// selectAffectedFiles narrows test files down to those affected by the changed paths.
+// Changed test files are always selected; changed modules (or sources sharing a module's
+// name, e.g. src/player.ts for dist/player.js) select the tests calling their functions.
+// When a change inside projectDir cannot be mapped with certainty, all files are returned
+// along with the reason.
+func selectAffectedFiles(files []string, changed []string, config *parser.Config, projectDir string) ([]string, string) {
+	testFiles := make(map[string]string) // absolute path -> path as given
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return files, fmt.Sprintf("cannot resolve %s", file)
+		}
+		testFiles[abs] = file
+	}
+
+	var modules []string
+	if config != nil {
+		modules = config.Modules
+	}
+
+	selected := make(map[string]bool)
+	affectedFunctions := make(map[string]bool)
+
+	for _, path := range changed {
+		if file, ok := testFiles[path]; ok {
+			selected[file] = true
+			continue
+		}
+
+		if filepath.Base(path) == "vyb.config.yaml" {
+			return files, "vyb.config.yaml changed"
+		}
+
+		if strings.HasSuffix(path, ".vyb") {
+			continue // Test file outside the pattern
+		}
+
+		matched := matchingModules(path, modules)
+		if len(matched) == 0 {
+			if len(modules) > 0 && sourceExtensions[filepath.Ext(path)] && isWithin(path, projectDir) {
+				return files, fmt.Sprintf("cannot map %s to a configured module", path)
+			}
+			continue // Not a source file (docs, assets, ...)
+		}
+
+		for _, module := range matched {
+			functions, err := moduleFunctions(module)
+			if err != nil || len(functions) == 0 {
+				return files, fmt.Sprintf("cannot determine functions exported by %s", module)
+			}
+			for _, name := range functions {
+				affectedFunctions[name] = true
+			}
+		}
+	}
+
+	var result []string
+	for _, file := range files {
+		if selected[file] {
+			result = append(result, file)
+			continue
+		}
+		if len(affectedFunctions) == 0 {
+			continue
+		}
+
+		testFile, err := parser.Parse(file)
+		if err != nil {
+			result = append(result, file) // Let the run surface the parse error
+			continue
+		}
+
+		if callsAny(testFile, affectedFunctions) {
+			result = append(result, file)
+		}
+	}
+
+	return result, ""
+}
+
+// matchingModules returns the configured modules that correspond to a changed path:
+// the module itself, or for source files one sharing its base name (src/player.ts ->
+// dist/player.js). Other files with a module's name (docs/player.md) don't match.
+func matchingModules(path string, modules []string) []string {
+	var matched []string
+	stem := fileStem(path)
+	for _, module := range modules {
+		if filepath.Clean(module) == filepath.Clean(path) {
+			matched = append(matched, module)
+		} else if sourceExtensions[filepath.Ext(path)] && fileStem(module) == stem {
+			matched = append(matched, module)
+		}
+	}
+	return matched
+}
+
+// isWithin reports whether path is located inside dir
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fileStem returns the base name of a path without any extensions (player.d.ts -> player)
+func fileStem(path string) string {
+	base := filepath.Base(path)
+	if i := strings.Index(base, "."); i > 0 {
+		return base[:i]
+	}
+	return base
+}
+
+// callsAny reports whether any test in the file calls one of the given functions
+func callsAny(testFile *parser.TestFile, functions map[string]bool) bool {
+	for i := range testFile.Tests {
+		for _, name := range calledFunctions(&testFile.Tests[i]) {
+			if functions[name] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+}
+
+func setupChangedProject(t *testing.T) (string, []string, *parser.Config) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "dist", "player.js"), `exports.createPlayer = createPlayer;
+function createPlayer(x, y) { return { x, y }; }
+`)
+	writeFile(t, filepath.Join(dir, "dist", "enemy.js"), `function spawnEnemy() { return {}; }
+exports.spawnEnemy = spawnEnemy;
+`)
+	writeFile(t, filepath.Join(dir, "tests", "player.ts.vyb"), `"creates player":
+  when:
+    - "player = createPlayer(1, 2)"
+  then:
+    - "expect: player.x == 1"
+`)
+	writeFile(t, filepath.Join(dir, "tests", "enemy.ts.vyb"), `"spawns enemy":
+  when:
+    - "enemy = spawnEnemy()"
+  then:
+    - "expect: enemy != 0"
+`)
+
+	files := []string{
+		filepath.Join(dir, "tests", "enemy.ts.vyb"),
+		filepath.Join(dir, "tests", "player.ts.vyb"),
+	}
+	config := &parser.Config{
+		Runtime: "node",
+		Modules: []string{
+			filepath.Join(dir, "dist", "player.js"),
+			filepath.Join(dir, "dist", "enemy.js"),
+		},
+	}
+
+	return dir, files, config
+}
+
+func TestSelectAffectedFilesBySourceName(t *testing.T) {
+	dir, files, config := setupChangedProject(t)
+
+	selected, fallback := selectAffectedFiles(files, []string{filepath.Join(dir, "src", "player.ts")}, config, dir)
+	if fallback != "" {
+		t.Fatalf("Expected no fallback, got %q", fallback)
+	}
+	if len(selected) != 1 || selected[0] != files[1] {
+		t.Errorf("Expected only player test, got %v", selected)
+	}
+}
+
+func TestSelectAffectedFilesChangedTest(t *testing.T) {
+	dir, files, config := setupChangedProject(t)
+
+	selected, _ := selectAffectedFiles(files, []string{files[0]}, config, dir)
+	if len(selected) != 1 || selected[0] != files[0] {
+		t.Errorf("Expected only enemy test, got %v", selected)
+	}
+}
+
+func TestSelectAffectedFilesIgnoresNonSource(t *testing.T) {
+	dir, files, config := setupChangedProject(t)
+
+	selected, fallback := selectAffectedFiles(files, []string{filepath.Join(dir, "README.md")}, config, dir)
+	if fallback != "" || len(selected) != 0 {
+		t.Errorf("Expected nothing selected, got %v (fallback %q)", selected, fallback)
+	}
+}
+
+func TestSelectAffectedFilesIgnoresFilesNamedLikeModules(t *testing.T) {
+	dir, files, config := setupChangedProject(t)
+
+	for _, path := range []string{filepath.Join(dir, "docs", "player.md"), filepath.Join(dir, "fixtures", "player.json")} {
+		selected, fallback := selectAffectedFiles(files, []string{path}, config, dir)
+		if fallback != "" || len(selected) != 0 {
+			t.Errorf("%s: expected nothing selected, got %v (fallback %q)", path, selected, fallback)
+		}
+	}
+}
+
+func TestSelectAffectedFilesFallback(t *testing.T) {
+	dir, files, config := setupChangedProject(t)
+
+	selected, fallback := selectAffectedFiles(files, []string{filepath.Join(dir, "src", "utils.ts")}, config, dir)
+	if fallback == "" {
+		t.Error("Expected fallback for unmapped source file")
+	}
+	if len(selected) != len(files) {
+		t.Errorf("Expected all files on fallback, got %v", selected)
+	}
+}
+
+func TestSelectAffectedFilesIgnoresOutsideProject(t *testing.T) {
+	dir, files, config := setupChangedProject(t)
+
+	selected, fallback := selectAffectedFiles(files, []string{filepath.Join(filepath.Dir(dir), "other", "main.go")}, config, dir)
+	if fallback != "" || len(selected) != 0 {
+		t.Errorf("Expected nothing selected, got %v (fallback %q)", selected, fallback)
+	}
+}
+
+func TestCalledFunctions(t *testing.T) {
+	test := &parser.Test{
+		When: []string{"player = createPlayer(1, 2)", "moved = movePlayer(player, add(1, 2))"},
+		Then: []string{"expect: moved.x == 3"},
+	}
+
+	names := calledFunctions(test)
+	expected := []string{"add", "createPlayer", "movePlayer"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for i, name := range expected {
+		if names[i] != name {
+			t.Errorf("Expected %s at %d, got %s", name, i, names[i])
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	suggestArtifactFile = ".vyb/results.yaml" // Full suggest output written in compact mode
+
+	compactValueLimit = 200  // Characters kept of actual/expected values
+	compactCodeLimit  = 1200 // Characters kept of test_code
+	compactDiffLines  = 20   // Diff lines kept
+	compactHints      = 3    // Hints kept per failure
+	charsPerToken     = 4    // Rough token estimate for English text and YAML
+)
+
+// suggestBudget configures compact suggest output
+type suggestBudget struct {
+	maxTokens int    // 0 = no limit
+	artifact  string // Where the full output is written
+}
+
+// compactSuggestOutput reduces suggest output to what an agent needs to act: failing
+// tests only, most confident first, identical failures merged, large values truncated,
+// and as many failures as fit in the token budget
+func compactSuggestOutput(full SuggestOutput, budget *suggestBudget) SuggestOutput {
+	var failures []SuggestTestResult
+	for _, result := range full.Tests {
+		if result.Status == "fail" {
+			failures = append(failures, result)
+		}
+	}
+
+	// A confident test failing is the most likely real bug, so it comes first
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Confidence > failures[j].Confidence
+	})
+
+	var unique []SuggestTestResult
+	seen := make(map[string]int)
+	for _, failure := range failures {
+		key := failureKey(failure)
+		if i, ok := seen[key]; ok {
+			unique[i].SameError = append(unique[i].SameError, failure.File+": "+failure.Name)
+			continue
+		}
+		seen[key] = len(unique)
+		unique = append(unique, truncateSuggestResult(failure, budget.artifact))
+	}
+
+	output := SuggestOutput{
+		Summary: full.Summary,
+		Tests:   []SuggestTestResult{},
+		Flaky:   full.Flaky,
+		Full:    budget.artifact,
+	}
+
+	// Summary, flaky list and message are always included
+	used := estimateTokens(output) + estimateTokens(compactMessage(output))
+	for i, result := range unique {
+		cost := estimateTokens(result)
+		if budget.maxTokens > 0 && i > 0 && used+cost > budget.maxTokens {
+			for _, omitted := range unique[i:] {
+				output.Omitted += 1 + len(omitted.SameError)
+			}
+			break
+		}
+		output.Tests = append(output.Tests, result)
+		used += cost
+	}
+
+	output.Message = compactMessage(output)
+	return output
+}
+
+// failureKey identifies failures that are the same problem: the same error code with the
+// same cause, or the same mismatch of values. The error text is not used because it
+// quotes the failing step, which differs between tests.
+func failureKey(failure SuggestTestResult) string {
+	if failure.Code == string(CodeAssertMismatch) {
+		return strings.Join([]string{failure.Code, failure.operator, formatValue(failure.Actual), formatValue(failure.Expected)}, "\x00")
+	}
+	cause := failure.cause
+	if cause == "" {
+		cause = failure.Error
+	}
+	return failure.Code + "\x00" + cause
+}
+
+// truncateSuggestResult shortens the large fields of a failure
+func truncateSuggestResult(result SuggestTestResult, artifact string) SuggestTestResult {
+	result.TestCode = truncateText(result.TestCode, compactCodeLimit, artifact)
+	if result.Actual != nil {
+		result.Actual = truncateValue(result.Actual, artifact)
+	}
+	if result.Expected != nil {
+		result.Expected = truncateValue(result.Expected, artifact)
+	}
+	if len(result.Diff) > compactDiffLines {
+		more := len(result.Diff) - compactDiffLines
+		result.Diff = append(result.Diff[:compactDiffLines:compactDiffLines],
+			fmt.Sprintf("... (%d more line(s), full diff in %s)", more, artifact))
+	}
+	if len(result.Hints) > compactHints {
+		result.Hints = result.Hints[:compactHints]
+	}
+	return result
+}
+
+// truncateValue keeps small values as they are and shortens large ones to text
+func truncateValue(v interface{}, artifact string) interface{} {
+	text := formatValue(v)
+	if len([]rune(text)) <= compactValueLimit {
+		return v
+	}
+	return truncateText(text, compactValueLimit, artifact)
+}
+
+// truncateText cuts text to limit characters, pointing at the full output
+func truncateText(text string, limit int, artifact string) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return fmt.Sprintf("%s... (truncated %d chars, see %s)", string(runes[:limit]), len(runes)-limit, artifact)
+}
+
+// compactMessage is the guidance shown with compact output
+func compactMessage(output SuggestOutput) string {
+	var sb strings.Builder
+	summary := output.Summary
+	if summary.Failed == 0 {
+		sb.WriteString("All tests passed!")
+	} else {
+		sb.WriteString(fmt.Sprintf("%d failing test(s), most confident first - fix the first one first. ", summary.Failed))
+		sb.WriteString("Identical failures are merged into same_error.")
+	}
+	if output.Omitted > 0 {
+		sb.WriteString(fmt.Sprintf(" %d more failure(s) omitted to fit the token budget.", output.Omitted))
+	}
+	if summary.NotRun > 0 {
+		sb.WriteString(fmt.Sprintf(" The run stopped early: %d test(s) were not run.", summary.NotRun))
+	}
+	if gate := summary.ConfidenceGate; gate != nil && !gate.Passed {
+		sb.WriteString(" " + gateMessage(gate))
+	}
+	if summary.Flaky > 0 {
+		sb.WriteString(fmt.Sprintf(" %d flaky test(s) passed only after retrying - don't change the implementation to fix them.", summary.Flaky))
+	}
+	if output.Full != "" {
+		sb.WriteString(" Full results: " + output.Full)
+	}
+	return sb.String()
+}
+
+// estimateTokens approximates how many tokens v takes up as YAML
+func estimateTokens(v interface{}) int {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data)/charsPerToken + 1
+}
+
+// writeSuggestOutput writes full suggest output to a file
+func writeSuggestOutput(path string, output SuggestOutput) error {
+	file, err := createOutputFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := yaml.NewEncoder(file)
+	encoder.SetIndent(2)
+	return encoder.Encode(output)
+}
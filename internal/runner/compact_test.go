@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestCompactSuggestOutput(t *testing.T) {
+	long := strings.Repeat("x", compactValueLimit+50)
+	full := SuggestOutput{
+		Summary: TestSummary{Total: 4, Passed: 1, Failed: 3},
+		Tests: []SuggestTestResult{
+			{Name: "passes", File: "a.vyb", Status: "pass", Confidence: 1.0},
+			{Name: "unsure", File: "a.vyb", Status: "fail", Error: "Expectation failed: expect: x == 3", Actual: 2, Expected: 3, Confidence: 0.5},
+			{Name: "sure", File: "a.vyb", Status: "fail", Error: "Expectation failed: expect: s == t", Actual: long, Expected: "t", Confidence: 0.95},
+			{Name: "unsure again", File: "b.vyb", Status: "fail", Error: "Expectation failed: expect: x == 3", Actual: 2, Expected: 3, Confidence: 0.5},
+		},
+	}
+
+	output := compactSuggestOutput(full, &suggestBudget{artifact: suggestArtifactFile})
+
+	if len(output.Tests) != 2 {
+		t.Fatalf("expected 2 failures after dropping passes and merging duplicates, got %d", len(output.Tests))
+	}
+	if output.Tests[0].Name != "sure" {
+		t.Errorf("expected the most confident failure first, got %q", output.Tests[0].Name)
+	}
+	if actual, _ := output.Tests[0].Actual.(string); !strings.Contains(actual, "truncated 50 chars, see "+suggestArtifactFile) {
+		t.Errorf("expected a truncated actual value, got %v", output.Tests[0].Actual)
+	}
+	if got := output.Tests[1].SameError; len(got) != 1 || got[0] != "b.vyb: unsure again" {
+		t.Errorf("expected the duplicate failure in same_error, got %v", got)
+	}
+}
+
+func TestCompactSuggestOutputRespectsBudget(t *testing.T) {
+	full := SuggestOutput{Summary: TestSummary{Total: 3, Failed: 3}}
+	for _, name := range []string{"one", "two", "three"} {
+		full.Tests = append(full.Tests, SuggestTestResult{
+			Name:     name,
+			File:     "a.vyb",
+			Status:   "fail",
+			Error:    "Expectation failed: " + name,
+			TestCode: strings.Repeat("when: something\n", 20),
+		})
+	}
+
+	output := compactSuggestOutput(full, &suggestBudget{maxTokens: 100, artifact: suggestArtifactFile})
+
+	if len(output.Tests) != 1 {
+		t.Fatalf("expected only the first failure to fit, got %d", len(output.Tests))
+	}
+	if output.Omitted != 2 {
+		t.Errorf("expected 2 omitted failures, got %d", output.Omitted)
+	}
+	if !strings.Contains(output.Message, "2 more failure(s) omitted") {
+		t.Errorf("message should mention omitted failures: %s", output.Message)
+	}
+}
+
+func TestCompactSuggestOutputMergesSameCause(t *testing.T) {
+	var full SuggestOutput
+	full.Summary = TestSummary{Total: 3, Failed: 3, ConfidenceGate: &ConfidenceGateReport{Failures: []string{"average confidence 0.60 < 0.80"}}}
+	for _, f := range []struct{ name, stmt, function string }{
+		{"first", "total = checkout(cart)", "checkout"},
+		{"second", "total = checkout(other)", "checkout"},
+		{"third", "x = parse(input)", "parse"},
+	} {
+		err := &callError{Function: f.function, Err: &BridgeError{Code: CodeExternalError, Message: "TypeError: cart is undefined"}}
+		full.Tests = append(full.Tests, newSuggestResult(TestReport{
+			File:   "cart.js.vyb",
+			Status: "fail",
+			Result: parser.TestResult{
+				Name:       f.name,
+				Error:      fmt.Sprintf("Failed to execute statement '%s': %v", f.stmt, err),
+				Cause:      errorCause(err),
+				Code:       string(CodeExternalError),
+				Confidence: 0.9,
+			},
+			Test: &parser.Test{},
+		}))
+	}
+
+	output := compactSuggestOutput(full, &suggestBudget{artifact: suggestArtifactFile})
+
+	// The same function throwing the same error merges; another function doesn't
+	if len(output.Tests) != 2 || output.Tests[0].Name != "first" || output.Tests[1].Name != "third" {
+		t.Fatalf("tests = %+v", output.Tests)
+	}
+	if got := output.Tests[0].SameError; len(got) != 1 || got[0] != "cart.js.vyb: second" {
+		t.Errorf("same_error = %v", got)
+	}
+	if !strings.Contains(output.Message, "The confidence gate failed (average confidence 0.60 < 0.80)") {
+		t.Errorf("message should keep the gate guidance: %s", output.Message)
+	}
+}
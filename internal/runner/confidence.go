@@ -0,0 +1,190 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// ExitConfidenceGate is the exit code of a run whose tests passed but whose confidence
+// gates did not (failing tests exit with 1)
+const ExitConfidenceGate = 3
+
+// ConfidenceRequirement requires every test in files matching Glob to state at least
+// Min confidence
+type ConfidenceRequirement struct {
+	Glob string
+	Min  float64
+}
+
+// ParseConfidenceRequirement parses a --require-confidence-for value: "glob=0.9"
+func ParseConfidenceRequirement(value string) (ConfidenceRequirement, error) {
+	glob, minText, ok := strings.Cut(value, "=")
+	glob = strings.TrimSpace(glob)
+	if !ok || glob == "" {
+		return ConfidenceRequirement{}, fmt.Errorf("invalid confidence requirement %q (use <glob>=<confidence>, e.g. 'tests/payments/**=0.9')", value)
+	}
+
+	min, err := parseConfidence(strings.TrimSpace(minText))
+	if err != nil {
+		return ConfidenceRequirement{}, fmt.Errorf("invalid confidence requirement %q: %w", value, err)
+	}
+	return ConfidenceRequirement{Glob: glob, Min: min}, nil
+}
+
+// parseConfidence parses a confidence between 0 and 1
+func parseConfidence(text string) (float64, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 || value > 1 {
+		return 0, fmt.Errorf("confidence must be a number between 0 and 1, got %q", text)
+	}
+	return value, nil
+}
+
+// ConfidenceGateReport is the outcome of the confidence gates, part of the summary
+type ConfidenceGateReport struct {
+	Passed        bool                `json:"passed" yaml:"passed"`
+	MinAverage    float64             `json:"min_average,omitempty" yaml:"min_average,omitempty"`
+	Failures      []string            `json:"failures,omitempty" yaml:"failures,omitempty"`             // Gates that were not met
+	LowConfidence []LowConfidenceTest `json:"low_confidence,omitempty" yaml:"low_confidence,omitempty"` // Tests below a gate, lowest first
+}
+
+// LowConfidenceTest is a test whose stated confidence is below a gate
+type LowConfidenceTest struct {
+	Name       string  `json:"name" yaml:"name"`
+	File       string  `json:"file" yaml:"file"`
+	Line       int     `json:"line,omitempty" yaml:"line,omitempty"`
+	Confidence float64 `json:"confidence" yaml:"confidence"`
+	Required   float64 `json:"required" yaml:"required"`
+	Gate       string  `json:"gate" yaml:"gate"` // "average" or the glob that requires the confidence
+}
+
+// confidenceGates are the thresholds a run's confidence must meet
+type confidenceGates struct {
+	minAverage float64
+	require    []compiledRequirement
+}
+
+// compiledRequirement is a ConfidenceRequirement with its glob compiled
+type compiledRequirement struct {
+	ConfidenceRequirement
+	pattern *regexp.Regexp
+}
+
+// newConfidenceGates combines the gates in vyb.config.yaml with those given as flags.
+// A flag overrides the minimum average and the requirement for the same glob. Returns
+// nil when there are no gates.
+func newConfidenceGates(config *parser.Config, opts Options) (*confidenceGates, error) {
+	gates := &confidenceGates{}
+	required := make(map[string]float64)
+
+	if config != nil {
+		gates.minAverage = config.Confidence.MinAverage
+		for glob, min := range config.Confidence.Require {
+			required[glob] = min
+		}
+	}
+	if opts.MinAvgConfidence != nil {
+		gates.minAverage = *opts.MinAvgConfidence
+	}
+	for _, requirement := range opts.RequireConfidence {
+		required[requirement.Glob] = requirement.Min
+	}
+
+	if gates.minAverage < 0 || gates.minAverage > 1 {
+		return nil, fmt.Errorf("confidence min_average must be between 0 and 1, got %g", gates.minAverage)
+	}
+
+	globs := make([]string, 0, len(required))
+	for glob := range required {
+		globs = append(globs, glob)
+	}
+	sort.Strings(globs)
+	for _, glob := range globs {
+		min := required[glob]
+		if min < 0 || min > 1 {
+			return nil, fmt.Errorf("required confidence for %s must be between 0 and 1, got %g", glob, min)
+		}
+		re, err := globPattern(glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid confidence requirement glob %q: %w", glob, err)
+		}
+		gates.require = append(gates.require, compiledRequirement{ConfidenceRequirement{Glob: glob, Min: min}, re})
+	}
+
+	if gates.minAverage == 0 && len(gates.require) == 0 {
+		return nil, nil
+	}
+	return gates, nil
+}
+
+// check evaluates the gates against the summary and the tests that ran
+func (g *confidenceGates) check(summary TestSummary, tests []LowConfidenceTest) *ConfidenceGateReport {
+	report := &ConfidenceGateReport{Passed: true, MinAverage: g.minAverage}
+	executed := summary.Passed + summary.Failed
+
+	// Each test below a gate is listed once, under the strictest gate it misses
+	low := make(map[int]LowConfidenceTest)
+	flag := func(i int, required float64, gate string) {
+		if listed, ok := low[i]; !ok || required > listed.Required {
+			test := tests[i]
+			test.Required, test.Gate = required, gate
+			low[i] = test
+		}
+	}
+
+	if g.minAverage > 0 && executed > 0 && summary.AverageConfidence < g.minAverage {
+		report.Failures = append(report.Failures, fmt.Sprintf("average confidence %.3f is below %g", summary.AverageConfidence, g.minAverage))
+		// The tests below the minimum are the ones dragging the average down
+		for i, test := range tests {
+			if test.Confidence < g.minAverage {
+				flag(i, g.minAverage, "average")
+			}
+		}
+	}
+
+	for _, requirement := range g.require {
+		below := 0
+		for i, test := range tests {
+			if matchesFile(requirement.pattern, test.File) && test.Confidence < requirement.Min {
+				below++
+				flag(i, requirement.Min, requirement.Glob)
+			}
+		}
+		if below > 0 {
+			report.Failures = append(report.Failures, fmt.Sprintf("%d test(s) in %s are below the required confidence %g", below, requirement.Glob, requirement.Min))
+		}
+	}
+
+	for i := range tests {
+		if test, ok := low[i]; ok {
+			report.LowConfidence = append(report.LowConfidence, test)
+		}
+	}
+	sort.SliceStable(report.LowConfidence, func(i, j int) bool {
+		return report.LowConfidence[i].Confidence < report.LowConfidence[j].Confidence
+	})
+	report.Passed = len(report.Failures) == 0
+	return report
+}
+
+// lowConfidenceMessage explains why a test is listed in the gate breakdown
+func lowConfidenceMessage(test LowConfidenceTest) string {
+	if test.Gate == "average" {
+		return fmt.Sprintf("Confidence %.2f is below the minimum average %.2f", test.Confidence, test.Required)
+	}
+	return fmt.Sprintf("Confidence %.2f is below %.2f, required for %s", test.Confidence, test.Required, test.Gate)
+}
+
+// ConfidenceGateError is returned by a run that failed its confidence gates
+type ConfidenceGateError struct {
+	Failures []string
+}
+
+func (e *ConfidenceGateError) Error() string {
+	return "confidence gate failed: " + strings.Join(e.Failures, "; ")
+}
@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestParseConfidenceRequirement(t *testing.T) {
+	requirement, err := ParseConfidenceRequirement("tests/payments/**=0.9")
+	if err != nil || requirement != (ConfidenceRequirement{Glob: "tests/payments/**", Min: 0.9}) {
+		t.Errorf("requirement = %+v, err = %v", requirement, err)
+	}
+
+	for _, value := range []string{"tests/**", "=0.9", "tests/**=high", "tests/**=1.5"} {
+		if _, err := ParseConfidenceRequirement(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestNewConfidenceGates(t *testing.T) {
+	if gates, err := newConfidenceGates(nil, Options{}); gates != nil || err != nil {
+		t.Errorf("Expected no gates, got %+v, %v", gates, err)
+	}
+
+	config := &parser.Config{Confidence: parser.ConfidenceConfig{
+		MinAverage: 0.8,
+		Require:    map[string]float64{"tests/**": 0.7, "billing/*": 0.9},
+	}}
+	opts := Options{MinAvgConfidence: floatPtr(0.85), RequireConfidence: []ConfidenceRequirement{{Glob: "tests/**", Min: 0.75}}}
+	gates, err := newConfidenceGates(config, opts)
+	if err != nil {
+		t.Fatalf("newConfidenceGates failed: %v", err)
+	}
+	if gates.minAverage != 0.85 {
+		t.Errorf("minAverage = %v, want the flag's 0.85", gates.minAverage)
+	}
+	var got []ConfidenceRequirement
+	for _, requirement := range gates.require {
+		got = append(got, requirement.ConfidenceRequirement)
+	}
+	want := []ConfidenceRequirement{{"billing/*", 0.9}, {"tests/**", 0.75}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requirements = %v, want %v", got, want)
+	}
+
+	// --min-avg-confidence 0 turns off the config's min_average
+	config.Confidence.Require = nil
+	if gates, err := newConfidenceGates(config, Options{MinAvgConfidence: floatPtr(0)}); gates != nil || err != nil {
+		t.Errorf("Expected no gates with the minimum overridden to 0, got %+v, %v", gates, err)
+	}
+
+	config.Confidence.MinAverage = 2
+	if _, err := newConfidenceGates(config, Options{}); err == nil {
+		t.Error("Expected error for min_average above 1")
+	}
+}
+
+func TestConfidenceGatesCheck(t *testing.T) {
+	gates, _ := newConfidenceGates(nil, Options{
+		MinAvgConfidence:  floatPtr(0.8),
+		RequireConfidence: []ConfidenceRequirement{{Glob: "payments/**", Min: 0.9}},
+	})
+	tests := []LowConfidenceTest{
+		{Name: "refunds", File: "payments/refund.vyb", Confidence: 0.85},
+		{Name: "guesses", File: "misc.vyb", Confidence: 0.5},
+		{Name: "charges", File: "payments/charge.vyb", Confidence: 0.7},
+		{Name: "adds", File: "math.vyb", Confidence: 1.0},
+	}
+	summary := TestSummary{Passed: 4, AverageConfidence: 0.7625}
+
+	report := gates.check(summary, tests)
+	if report.Passed || len(report.Failures) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	if !strings.Contains(report.Failures[0], "average confidence 0.762 is below 0.8") || !strings.Contains(report.Failures[1], "2 test(s) in payments/**") {
+		t.Errorf("failures = %v", report.Failures)
+	}
+
+	var names, gatesMissed []string
+	for _, test := range report.LowConfidence {
+		names = append(names, test.Name)
+		gatesMissed = append(gatesMissed, test.Gate)
+	}
+	// Lowest first, each test once under the strictest gate it misses
+	if !reflect.DeepEqual(names, []string{"guesses", "charges", "refunds"}) || !reflect.DeepEqual(gatesMissed, []string{"average", "payments/**", "payments/**"}) {
+		t.Errorf("low confidence = %v %v", names, gatesMissed)
+	}
+
+	summary.AverageConfidence = 0.9
+	for i := range tests {
+		tests[i].Confidence = 0.95
+	}
+	if report := gates.check(summary, tests); !report.Passed || len(report.LowConfidence) != 0 {
+		t.Errorf("Expected the gates to pass, got %+v", report)
+	}
+}
@@ -12,6 +12,18 @@ type Bridge interface {
 	Call(functionName string, args []interface{}) (interface{}, error)
 }
 
+// FunctionLister is implemented by bridges that can list the functions their modules
+// export, which lets unknown function errors suggest the intended name
+type FunctionLister interface {
+	Functions() ([]string, error)
+}
+
+// builtinFunctions are the functions callFunction implements itself
+var builtinFunctions = []string{
+	"add", "multiply", "subtract", "celsiusToFahrenheit", "divide", "power", "sqrt",
+	"abs", "min", "max", "concat", "toUpper", "toLower",
+}
+
 // Context holds variables and state during test execution
 type Context struct {
 	vars   map[string]interface{}
@@ -81,7 +93,7 @@ func (c *Context) Eval(expr string) (interface{}, error) {
 		// Get the object
 		obj, ok := c.Get(objName)
 		if !ok {
-			return nil, fmt.Errorf("undefined variable: %s", objName)
+			return nil, c.undefinedVariable(objName)
 		}
 
 		// Access the property (handle nested properties like obj.a.b)
@@ -91,17 +103,64 @@ func (c *Context) Eval(expr string) (interface{}, error) {
 	// Otherwise, treat as variable reference
 	val, ok := c.Get(expr)
 	if !ok {
-		return nil, fmt.Errorf("undefined variable: %s", expr)
+		return nil, c.undefinedVariable(expr)
 	}
 	return val, nil
 }
 
+// undefinedVariable reports a variable that isn't in scope, suggesting similar names
+// that are
+func (c *Context) undefinedVariable(name string) error {
+	var names []string
+	for varName := range c.vars {
+		names = append(names, varName)
+	}
+
+	suggestions := nearestNames(name, names)
+	return &CodedError{
+		Code:        CodeUndefinedVar,
+		Message:     withSuggestions(fmt.Sprintf("undefined variable: %s", name), suggestions),
+		Suggestions: suggestions,
+	}
+}
+
+// unknownFunction reports a function that is neither built in nor exported by the
+// bridge's modules (bridgeErr is the bridge's answer), suggesting similar names
+func (c *Context) unknownFunction(name string, bridgeErr error) error {
+	candidates := append([]string{}, builtinFunctions...)
+	if lister, ok := c.bridge.(FunctionLister); ok {
+		if exported, err := lister.Functions(); err == nil {
+			candidates = append(candidates, exported...)
+		}
+	}
+	suggestions := nearestNames(name, candidates)
+
+	message := fmt.Sprintf("unknown function: %s (not a built-in, no external modules configured)", name)
+	if bridgeErr != nil {
+		message = fmt.Sprintf("external function %s() failed: %v", name, bridgeErr)
+	}
+	return &CodedError{
+		Code:        CodeUnknownFunc,
+		Message:     withSuggestions(message, suggestions),
+		Err:         bridgeErr,
+		Suggestions: suggestions,
+	}
+}
+
+// withSuggestions appends "(did you mean ...?)" to message when there are suggestions
+func withSuggestions(message string, suggestions []string) string {
+	if len(suggestions) == 0 {
+		return message
+	}
+	return message + " (" + didYouMean(suggestions) + ")"
+}
+
 // evalFunctionCall evaluates a function call
 func (c *Context) evalFunctionCall(expr string) (interface{}, error) {
 	// Parse: funcName(arg1, arg2, ...)
 	openParen := strings.Index(expr, "(")
 	if openParen == -1 {
-		return nil, fmt.Errorf("invalid function call: %s", expr)
+		return nil, newError(CodeInvalidExpression, "invalid function call: %s", expr)
 	}
 
 	funcName := strings.TrimSpace(expr[:openParen])
@@ -129,121 +188,121 @@ func (c *Context) callFunction(name string, args []interface{}) (interface{}, er
 	switch name {
 	case "add":
 		if len(args) != 2 {
-			return nil, fmt.Errorf("add() requires 2 arguments")
+			return nil, newError(CodeInvalidArgs, "add() requires 2 arguments")
 		}
 		a, ok1 := toFloat(args[0])
 		b, ok2 := toFloat(args[1])
 		if !ok1 || !ok2 {
-			return nil, fmt.Errorf("add() arguments must be numbers")
+			return nil, newError(CodeTypeMismatch, "add() arguments must be numbers")
 		}
 		return a + b, nil
 
 	case "multiply":
 		if len(args) != 2 {
-			return nil, fmt.Errorf("multiply() requires 2 arguments")
+			return nil, newError(CodeInvalidArgs, "multiply() requires 2 arguments")
 		}
 		a, ok1 := toFloat(args[0])
 		b, ok2 := toFloat(args[1])
 		if !ok1 || !ok2 {
-			return nil, fmt.Errorf("multiply() arguments must be numbers")
+			return nil, newError(CodeTypeMismatch, "multiply() arguments must be numbers")
 		}
 		return a * b, nil
 
 	case "subtract":
 		if len(args) != 2 {
-			return nil, fmt.Errorf("subtract() requires 2 arguments")
+			return nil, newError(CodeInvalidArgs, "subtract() requires 2 arguments")
 		}
 		a, ok1 := toFloat(args[0])
 		b, ok2 := toFloat(args[1])
 		if !ok1 || !ok2 {
-			return nil, fmt.Errorf("subtract() arguments must be numbers")
+			return nil, newError(CodeTypeMismatch, "subtract() arguments must be numbers")
 		}
 		return a - b, nil
 
 	case "celsiusToFahrenheit":
 		if len(args) != 1 {
-			return nil, fmt.Errorf("celsiusToFahrenheit() requires 1 argument")
+			return nil, newError(CodeInvalidArgs, "celsiusToFahrenheit() requires 1 argument")
 		}
 		celsius, ok := toFloat(args[0])
 		if !ok {
-			return nil, fmt.Errorf("celsiusToFahrenheit() argument must be a number")
+			return nil, newError(CodeTypeMismatch, "celsiusToFahrenheit() argument must be a number")
 		}
 		// Formula: F = (C × 9/5) + 32
 		return (celsius * 9.0 / 5.0) + 32.0, nil
 
 	case "divide":
 		if len(args) != 2 {
-			return nil, fmt.Errorf("divide() requires 2 arguments")
+			return nil, newError(CodeInvalidArgs, "divide() requires 2 arguments")
 		}
 		a, ok1 := toFloat(args[0])
 		b, ok2 := toFloat(args[1])
 		if !ok1 || !ok2 {
-			return nil, fmt.Errorf("divide() arguments must be numbers")
+			return nil, newError(CodeTypeMismatch, "divide() arguments must be numbers")
 		}
 		if b == 0 {
-			return nil, fmt.Errorf("division by zero")
+			return nil, newError(CodeDivisionByZero, "division by zero")
 		}
 		return a / b, nil
 
 	case "power":
 		if len(args) != 2 {
-			return nil, fmt.Errorf("power() requires 2 arguments")
+			return nil, newError(CodeInvalidArgs, "power() requires 2 arguments")
 		}
 		base, ok1 := toFloat(args[0])
 		exp, ok2 := toFloat(args[1])
 		if !ok1 || !ok2 {
-			return nil, fmt.Errorf("power() arguments must be numbers")
+			return nil, newError(CodeTypeMismatch, "power() arguments must be numbers")
 		}
 		return math.Pow(base, exp), nil
 
 	case "sqrt":
 		if len(args) != 1 {
-			return nil, fmt.Errorf("sqrt() requires 1 argument")
+			return nil, newError(CodeInvalidArgs, "sqrt() requires 1 argument")
 		}
 		n, ok := toFloat(args[0])
 		if !ok {
-			return nil, fmt.Errorf("sqrt() argument must be a number")
+			return nil, newError(CodeTypeMismatch, "sqrt() argument must be a number")
 		}
 		if n < 0 {
-			return nil, fmt.Errorf("sqrt() cannot be called with negative number")
+			return nil, newError(CodeInvalidArgs, "sqrt() cannot be called with negative number")
 		}
 		return math.Sqrt(n), nil
 
 	case "abs":
 		if len(args) != 1 {
-			return nil, fmt.Errorf("abs() requires 1 argument")
+			return nil, newError(CodeInvalidArgs, "abs() requires 1 argument")
 		}
 		n, ok := toFloat(args[0])
 		if !ok {
-			return nil, fmt.Errorf("abs() argument must be a number")
+			return nil, newError(CodeTypeMismatch, "abs() argument must be a number")
 		}
 		return math.Abs(n), nil
 
 	case "min":
 		if len(args) != 2 {
-			return nil, fmt.Errorf("min() requires 2 arguments")
+			return nil, newError(CodeInvalidArgs, "min() requires 2 arguments")
 		}
 		a, ok1 := toFloat(args[0])
 		b, ok2 := toFloat(args[1])
 		if !ok1 || !ok2 {
-			return nil, fmt.Errorf("min() arguments must be numbers")
+			return nil, newError(CodeTypeMismatch, "min() arguments must be numbers")
 		}
 		return math.Min(a, b), nil
 
 	case "max":
 		if len(args) != 2 {
-			return nil, fmt.Errorf("max() requires 2 arguments")
+			return nil, newError(CodeInvalidArgs, "max() requires 2 arguments")
 		}
 		a, ok1 := toFloat(args[0])
 		b, ok2 := toFloat(args[1])
 		if !ok1 || !ok2 {
-			return nil, fmt.Errorf("max() arguments must be numbers")
+			return nil, newError(CodeTypeMismatch, "max() arguments must be numbers")
 		}
 		return math.Max(a, b), nil
 
 	case "concat":
 		if len(args) < 2 {
-			return nil, fmt.Errorf("concat() requires at least 2 arguments")
+			return nil, newError(CodeInvalidArgs, "concat() requires at least 2 arguments")
 		}
 		result := fmt.Sprintf("%v", args[0])
 		for i := 1; i < len(args); i++ {
@@ -253,14 +312,14 @@ func (c *Context) callFunction(name string, args []interface{}) (interface{}, er
 
 	case "toUpper":
 		if len(args) != 1 {
-			return nil, fmt.Errorf("toUpper() requires 1 argument")
+			return nil, newError(CodeInvalidArgs, "toUpper() requires 1 argument")
 		}
 		str := fmt.Sprintf("%v", args[0])
 		return strings.ToUpper(str), nil
 
 	case "toLower":
 		if len(args) != 1 {
-			return nil, fmt.Errorf("toLower() requires 1 argument")
+			return nil, newError(CodeInvalidArgs, "toLower() requires 1 argument")
 		}
 		str := fmt.Sprintf("%v", args[0])
 		return strings.ToLower(str), nil
@@ -270,21 +329,39 @@ func (c *Context) callFunction(name string, args []interface{}) (interface{}, er
 		if c.bridge != nil {
 			result, err := c.bridge.Call(name, args)
 			if err != nil {
-				return nil, fmt.Errorf("external function %s() failed: %w", name, err)
+				if errorCode(err) == CodeUnknownFunc {
+					return nil, c.unknownFunction(name, err)
+				}
+				return nil, &callError{Function: name, Err: err}
 			}
 			return result, nil
 		}
 
 		// No bridge configured or function not found
-		return nil, fmt.Errorf("unknown function: %s (not a built-in, no external modules configured)", name)
+		return nil, c.unknownFunction(name, nil)
 	}
 }
 
+// callError is a failed call to an external function
+type callError struct {
+	Function string
+	Err      error
+}
+
+func (e *callError) Error() string {
+	return fmt.Sprintf("external function %s() failed: %v", e.Function, e.Err)
+}
+
+func (e *callError) Unwrap() error {
+	return e.Err
+}
+
 // ExpectationResult contains detailed information about an expectation check
 type ExpectationResult struct {
 	Passed   bool
 	Actual   interface{}
 	Expected interface{}
+	Operator string // "==", "!=", ">", "contains", ...
 	Error    error
 }
 
@@ -293,7 +370,7 @@ func (c *Context) CheckExpectation(expectStr string) ExpectationResult {
 	// Parse expectation: "result == 5" or "result > 10", etc.
 	expectStr = strings.TrimSpace(expectStr)
 	if !strings.HasPrefix(expectStr, "expect:") {
-		return ExpectationResult{Error: fmt.Errorf("expectation must start with 'expect:'")}
+		return ExpectationResult{Error: newError(CodeInvalidExpectation, "expectation must start with 'expect:'")}
 	}
 
 	expectStr = strings.TrimSpace(strings.TrimPrefix(expectStr, "expect:"))
@@ -302,40 +379,40 @@ func (c *Context) CheckExpectation(expectStr string) ExpectationResult {
 	if strings.Contains(expectStr, " contains ") {
 		parts := strings.Split(expectStr, " contains ")
 		if len(parts) != 2 {
-			return ExpectationResult{Error: fmt.Errorf("invalid contains expectation: %s", expectStr)}
+			return ExpectationResult{Error: newError(CodeInvalidExpectation, "invalid contains expectation: %s", expectStr)}
 		}
 		left, _ := c.Eval(strings.TrimSpace(parts[0]))
 		right, _ := c.Eval(strings.TrimSpace(parts[1]))
 		leftStr := fmt.Sprintf("%v", left)
 		rightStr := fmt.Sprintf("%v", right)
 		passed := strings.Contains(leftStr, rightStr)
-		return ExpectationResult{Passed: passed, Actual: leftStr, Expected: "contains " + rightStr}
+		return ExpectationResult{Passed: passed, Actual: leftStr, Expected: "contains " + rightStr, Operator: "contains"}
 	}
 
 	if strings.Contains(expectStr, " startsWith ") {
 		parts := strings.Split(expectStr, " startsWith ")
 		if len(parts) != 2 {
-			return ExpectationResult{Error: fmt.Errorf("invalid startsWith expectation: %s", expectStr)}
+			return ExpectationResult{Error: newError(CodeInvalidExpectation, "invalid startsWith expectation: %s", expectStr)}
 		}
 		left, _ := c.Eval(strings.TrimSpace(parts[0]))
 		right, _ := c.Eval(strings.TrimSpace(parts[1]))
 		leftStr := fmt.Sprintf("%v", left)
 		rightStr := fmt.Sprintf("%v", right)
 		passed := strings.HasPrefix(leftStr, rightStr)
-		return ExpectationResult{Passed: passed, Actual: leftStr, Expected: "startsWith " + rightStr}
+		return ExpectationResult{Passed: passed, Actual: leftStr, Expected: "startsWith " + rightStr, Operator: "startsWith"}
 	}
 
 	if strings.Contains(expectStr, " endsWith ") {
 		parts := strings.Split(expectStr, " endsWith ")
 		if len(parts) != 2 {
-			return ExpectationResult{Error: fmt.Errorf("invalid endsWith expectation: %s", expectStr)}
+			return ExpectationResult{Error: newError(CodeInvalidExpectation, "invalid endsWith expectation: %s", expectStr)}
 		}
 		left, _ := c.Eval(strings.TrimSpace(parts[0]))
 		right, _ := c.Eval(strings.TrimSpace(parts[1]))
 		leftStr := fmt.Sprintf("%v", left)
 		rightStr := fmt.Sprintf("%v", right)
 		passed := strings.HasSuffix(leftStr, rightStr)
-		return ExpectationResult{Passed: passed, Actual: leftStr, Expected: "endsWith " + rightStr}
+		return ExpectationResult{Passed: passed, Actual: leftStr, Expected: "endsWith " + rightStr, Operator: "endsWith"}
 	}
 
 	// Parse comparison operators
@@ -344,7 +421,7 @@ func (c *Context) CheckExpectation(expectStr string) ExpectationResult {
 		if strings.Contains(expectStr, op) {
 			parts := strings.Split(expectStr, op)
 			if len(parts) != 2 {
-				return ExpectationResult{Error: fmt.Errorf("invalid expectation: %s", expectStr)}
+				return ExpectationResult{Error: newError(CodeInvalidExpectation, "invalid expectation: %s", expectStr)}
 			}
 
 			left, err := c.Eval(strings.TrimSpace(parts[0]))
@@ -358,11 +435,11 @@ func (c *Context) CheckExpectation(expectStr string) ExpectationResult {
 			}
 
 			passed, compErr := compare(left, right, op)
-			return ExpectationResult{Passed: passed, Actual: left, Expected: right, Error: compErr}
+			return ExpectationResult{Passed: passed, Actual: left, Expected: right, Operator: op, Error: compErr}
 		}
 	}
 
-	return ExpectationResult{Error: fmt.Errorf("no comparison operator found in: %s", expectStr)}
+	return ExpectationResult{Error: newError(CodeInvalidExpectation, "no comparison operator found in: %s", expectStr)}
 }
 
 // compare compares two values with an operator
@@ -398,7 +475,7 @@ func compare(left, right interface{}, op string) (bool, error) {
 	case "!=":
 		return leftStr != rightStr, nil
 	default:
-		return false, fmt.Errorf("operator %s not supported for strings", op)
+		return false, newError(CodeTypeMismatch, "operator %s not supported for strings", op)
 	}
 }
 
@@ -427,7 +504,7 @@ func (c *Context) accessProperty(obj interface{}, propertyPath string) (interfac
 	case map[string]interface{}:
 		val, ok := objMap[property]
 		if !ok {
-			return nil, fmt.Errorf("property not found: %s", property)
+			return nil, newError(CodeUnknownProperty, "property not found: %s", property)
 		}
 
 		// If there are more parts, recurse
@@ -437,7 +514,7 @@ func (c *Context) accessProperty(obj interface{}, propertyPath string) (interfac
 		return val, nil
 
 	default:
-		return nil, fmt.Errorf("cannot access property %s on non-object type %T", property, obj)
+		return nil, newError(CodeTypeMismatch, "cannot access property %s on non-object type %T", property, obj)
 	}
 }
 
@@ -454,7 +531,7 @@ func (c *Context) SetProperty(obj interface{}, propertyPath string, value interf
 		if len(parts) > 1 {
 			nestedObj, ok := objMap[property]
 			if !ok {
-				return fmt.Errorf("property not found: %s", property)
+				return newError(CodeUnknownProperty, "property not found: %s", property)
 			}
 			return c.SetProperty(nestedObj, parts[1], value)
 		}
@@ -464,6 +541,6 @@ func (c *Context) SetProperty(obj interface{}, propertyPath string, value interf
 		return nil
 
 	default:
-		return fmt.Errorf("cannot set property %s on non-object type %T", property, obj)
+		return newError(CodeTypeMismatch, "cannot set property %s on non-object type %T", property, obj)
 	}
 }
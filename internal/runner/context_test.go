@@ -254,7 +254,8 @@ func TestCheckExpectationEqual(t *testing.T) {
 	ctx := NewContext()
 	ctx.Set("result", 5)
 
-	passed, err := ctx.CheckExpectation("expect: result == 5")
+	check := ctx.CheckExpectation("expect: result == 5")
+	passed, err := check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -262,7 +263,8 @@ func TestCheckExpectationEqual(t *testing.T) {
 		t.Error("Expected expectation to pass")
 	}
 
-	passed, err = ctx.CheckExpectation("expect: result == 10")
+	check = ctx.CheckExpectation("expect: result == 10")
+	passed, err = check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -275,7 +277,8 @@ func TestCheckExpectationNotEqual(t *testing.T) {
 	ctx := NewContext()
 	ctx.Set("result", 5)
 
-	passed, err := ctx.CheckExpectation("expect: result != 10")
+	check := ctx.CheckExpectation("expect: result != 10")
+	passed, err := check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -288,7 +291,8 @@ func TestCheckExpectationGreaterThan(t *testing.T) {
 	ctx := NewContext()
 	ctx.Set("result", 10)
 
-	passed, err := ctx.CheckExpectation("expect: result > 5")
+	check := ctx.CheckExpectation("expect: result > 5")
+	passed, err := check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -296,7 +300,8 @@ func TestCheckExpectationGreaterThan(t *testing.T) {
 		t.Error("Expected expectation to pass")
 	}
 
-	passed, err = ctx.CheckExpectation("expect: result > 15")
+	check = ctx.CheckExpectation("expect: result > 15")
+	passed, err = check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -309,7 +314,8 @@ func TestCheckExpectationLessThan(t *testing.T) {
 	ctx := NewContext()
 	ctx.Set("result", 10)
 
-	passed, err := ctx.CheckExpectation("expect: result < 15")
+	check := ctx.CheckExpectation("expect: result < 15")
+	passed, err := check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -317,7 +323,8 @@ func TestCheckExpectationLessThan(t *testing.T) {
 		t.Error("Expected expectation to pass")
 	}
 
-	passed, err = ctx.CheckExpectation("expect: result < 5")
+	check = ctx.CheckExpectation("expect: result < 5")
+	passed, err = check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -330,7 +337,8 @@ func TestCheckExpectationContains(t *testing.T) {
 	ctx := NewContext()
 	ctx.Set("text", "Hello World")
 
-	passed, err := ctx.CheckExpectation("expect: text contains \"World\"")
+	check := ctx.CheckExpectation("expect: text contains \"World\"")
+	passed, err := check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -338,7 +346,8 @@ func TestCheckExpectationContains(t *testing.T) {
 		t.Error("Expected expectation to pass")
 	}
 
-	passed, err = ctx.CheckExpectation("expect: text contains \"Goodbye\"")
+	check = ctx.CheckExpectation("expect: text contains \"Goodbye\"")
+	passed, err = check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -351,7 +360,8 @@ func TestCheckExpectationStartsWith(t *testing.T) {
 	ctx := NewContext()
 	ctx.Set("url", "https://example.com")
 
-	passed, err := ctx.CheckExpectation("expect: url startsWith \"https://\"")
+	check := ctx.CheckExpectation("expect: url startsWith \"https://\"")
+	passed, err := check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -359,7 +369,8 @@ func TestCheckExpectationStartsWith(t *testing.T) {
 		t.Error("Expected expectation to pass")
 	}
 
-	passed, err = ctx.CheckExpectation("expect: url startsWith \"http://\"")
+	check = ctx.CheckExpectation("expect: url startsWith \"http://\"")
+	passed, err = check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -372,7 +383,8 @@ func TestCheckExpectationEndsWith(t *testing.T) {
 	ctx := NewContext()
 	ctx.Set("filename", "document.txt")
 
-	passed, err := ctx.CheckExpectation("expect: filename endsWith \".txt\"")
+	check := ctx.CheckExpectation("expect: filename endsWith \".txt\"")
+	passed, err := check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
@@ -380,7 +392,8 @@ func TestCheckExpectationEndsWith(t *testing.T) {
 		t.Error("Expected expectation to pass")
 	}
 
-	passed, err = ctx.CheckExpectation("expect: filename endsWith \".pdf\"")
+	check = ctx.CheckExpectation("expect: filename endsWith \".pdf\"")
+	passed, err = check.Passed, check.Error
 	if err != nil {
 		t.Fatalf("CheckExpectation failed: %v", err)
 	}
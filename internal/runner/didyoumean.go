@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"sort"
+	"strings"
+)
+
+const maxSuggestions = 3 // Names offered in a "did you mean" suggestion
+
+// nearestNames returns the candidates name was most likely meant to be, best first.
+// Names that differ only in case or in camelCase/snake_case spelling match exactly;
+// otherwise the edit distance must be small relative to the name's length.
+func nearestNames(name string, candidates []string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	target := normalizeName(name)
+	limit := len(target) / 3
+	if limit < 1 {
+		limit = 1
+	}
+
+	var matches []match
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if candidate == name || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		distance := editDistance(target, normalizeName(candidate))
+		if distance <= limit {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	// Only offer names about as close as the best one: an exact match in another
+	// spelling makes everything else noise
+	var names []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		best := matches[0].distance
+		if matches[i].distance > best+1 || (best == 0 && matches[i].distance > 0) {
+			break
+		}
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// normalizeName folds case and word separators, so createPlayer, create_player and
+// CreatePlayer compare equal
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	return strings.NewReplacer("_", "", "-", "").Replace(name)
+}
+
+// editDistance is the optimal string alignment distance between a and b: insertions,
+// deletions, substitutions and swaps of adjacent characters each cost 1
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+// didYouMean formats suggestions for an error message, e.g. "did you mean a or b?"
+func didYouMean(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return "did you mean " + names[0] + "?"
+	default:
+		return "did you mean " + strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1] + "?"
+	}
+}
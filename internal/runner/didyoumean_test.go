@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestNearestNames(t *testing.T) {
+	candidates := []string{"createPlayer", "createPlanet", "player", "players", "deletePlayer", "score"}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"createplayer", []string{"createPlayer"}},  // case
+		{"create_player", []string{"createPlayer"}}, // snake case
+		{"craetePlayer", []string{"createPlayer"}},  // swapped letters
+		{"createPlayr", []string{"createPlayer"}},   // much closer than createPlanet
+		{"plyer", []string{"player"}},
+		{"playerz", []string{"player", "players"}}, // equally close
+		{"totallyDifferent", nil},
+		{"x", nil},
+	}
+
+	for _, tt := range tests {
+		got := nearestNames(tt.name, candidates)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("nearestNames(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "abc", 3},
+		{"check", "check", 0},
+		{"chekc", "check", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// listingBridge exports a fixed set of functions, none of which can be called
+type listingBridge struct {
+	names []string
+}
+
+func (b *listingBridge) Call(functionName string, args []interface{}) (interface{}, error) {
+	return nil, &BridgeError{Code: CodeUnknownFunc, Message: "Function not found: " + functionName}
+}
+
+func (b *listingBridge) Functions() ([]string, error) {
+	return b.names, nil
+}
+
+func TestRunTestSuggestsNames(t *testing.T) {
+	test := &parser.Test{
+		Given: map[string]interface{}{"player_health": 5.0},
+		When:  []string{"x = add(playerHealth, 1)"},
+		Then:  []string{"expect: x == 6"},
+	}
+	result := runTest(test, nil, nil)
+	if !reflect.DeepEqual(result.Suggestions, []string{"player_health"}) {
+		t.Errorf("variable suggestions = %v", result.Suggestions)
+	}
+	if !strings.Contains(result.Error, "did you mean player_health?") {
+		t.Errorf("error = %q", result.Error)
+	}
+	if hints := generateHints(test, result); !strings.HasPrefix(hints[0], "Did you mean player_health?") {
+		t.Errorf("first hint = %q", hints[0])
+	}
+
+	test = &parser.Test{When: []string{"p = create_player(100)"}, Then: []string{"expect: p == 1"}}
+	result = runTest(test, &listingBridge{names: []string{"createPlayer", "movePlayer"}}, nil)
+	if result.Code != string(CodeUnknownFunc) || !reflect.DeepEqual(result.Suggestions, []string{"createPlayer"}) {
+		t.Errorf("function suggestions = %v (%s)", result.Suggestions, result.Code)
+	}
+
+	test = &parser.Test{When: []string{"x = multipy(2, 3)"}, Then: []string{"expect: x == 6"}}
+	result = runTest(test, nil, nil)
+	if !reflect.DeepEqual(result.Suggestions, []string{"multiply"}) {
+		t.Errorf("built-in suggestions = %v", result.Suggestions)
+	}
+}
+
+func TestBuiltinFunctionsAreImplemented(t *testing.T) {
+	ctx := NewContext()
+	for _, name := range builtinFunctions {
+		if _, err := ctx.callFunction(name, nil); errorCode(err) == CodeUnknownFunc {
+			t.Errorf("%s is listed as a built-in but not implemented", name)
+		}
+	}
+}
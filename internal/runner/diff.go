@@ -0,0 +1,447 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// Diff kinds, chosen from the shape of the compared values
+const (
+	diffLines = "lines" // Multi-line strings
+	diffChars = "chars" // Single-line strings
+	diffPaths = "paths" // Maps and lists
+)
+
+const (
+	diffContext  = 2    // Unchanged lines shown around each changed line
+	diffMaxPaths = 20   // Path differences listed before summarizing the rest
+	diffMaxCells = 1e6  // LCS table size limit; larger inputs only trim common prefix/suffix
+	diffMinMatch = 0.35 // Below this share of common characters a char diff is just noise
+)
+
+// diffOp says which side a segment belongs to
+type diffOp int
+
+const (
+	diffEqual    diffOp = iota
+	diffExpected        // Only in the expected value
+	diffActual          // Only in the actual value
+)
+
+type diffSegment struct {
+	Op   diffOp
+	Text string
+}
+
+// pathDifference is one mismatch inside a structure
+type pathDifference struct {
+	Path     string
+	Actual   interface{}
+	Expected interface{}
+	Missing  bool // Expected but not present in actual
+	Extra    bool // Present in actual but not expected
+}
+
+// ValueDiff describes how an actual value differs from the expected one
+type ValueDiff struct {
+	Kind     string
+	Segments []diffSegment    // lines or chars
+	Paths    []pathDifference // paths
+	Omitted  int              // Path differences not listed
+}
+
+// newResultDiff diffs the values of a failed equality expectation (nil when a
+// diff would not add anything to the raw values, e.g. for numbers)
+func newResultDiff(result parser.TestResult) *ValueDiff {
+	if result.Passed || result.Operator != "==" {
+		return nil
+	}
+	return diffValues(result.Actual, result.Expected)
+}
+
+// diffValues picks a diff based on the value types
+func diffValues(actual, expected interface{}) *ValueDiff {
+	actualStr, actualIsStr := actual.(string)
+	expectedStr, expectedIsStr := expected.(string)
+	if actualIsStr && expectedIsStr {
+		if actualStr == expectedStr {
+			return nil
+		}
+		if strings.Contains(actualStr, "\n") || strings.Contains(expectedStr, "\n") {
+			return &ValueDiff{Kind: diffLines, Segments: diffSequences(splitLines(expectedStr), splitLines(actualStr))}
+		}
+		return &ValueDiff{Kind: diffChars, Segments: diffCharacters(expectedStr, actualStr)}
+	}
+
+	if isStructure(actual) && isStructure(expected) {
+		d := &ValueDiff{Kind: diffPaths}
+		collectPathDiffs("", actual, expected, &d.Paths)
+		if len(d.Paths) == 0 {
+			return nil
+		}
+		if len(d.Paths) > diffMaxPaths {
+			d.Omitted = len(d.Paths) - diffMaxPaths
+			d.Paths = d.Paths[:diffMaxPaths]
+		}
+		return d
+	}
+
+	return nil
+}
+
+// Compact renders the diff as short lines for suggest/JSON output.
+// Lines use "- " for expected and "+ " for actual; chars mark [-expected-]{+actual+}.
+func (d *ValueDiff) Compact() []string {
+	if d == nil {
+		return nil
+	}
+
+	switch d.Kind {
+	case diffChars:
+		var sb strings.Builder
+		for _, segment := range d.Segments {
+			switch segment.Op {
+			case diffExpected:
+				sb.WriteString("[-" + segment.Text + "-]")
+			case diffActual:
+				sb.WriteString("{+" + segment.Text + "+}")
+			default:
+				sb.WriteString(segment.Text)
+			}
+		}
+		return []string{sb.String()}
+
+	case diffLines:
+		var lines []string
+		for _, segment := range collapseContext(d.Segments) {
+			switch segment.Op {
+			case diffExpected:
+				lines = append(lines, "- "+segment.Text)
+			case diffActual:
+				lines = append(lines, "+ "+segment.Text)
+			default:
+				lines = append(lines, "  "+segment.Text)
+			}
+		}
+		return lines
+
+	default:
+		var lines []string
+		for _, p := range d.Paths {
+			lines = append(lines, p.String())
+		}
+		if d.Omitted > 0 {
+			lines = append(lines, fmt.Sprintf("... and %d more difference(s)", d.Omitted))
+		}
+		return lines
+	}
+}
+
+// Pretty renders the diff with colors for terminal output, each line prefixed by indent
+func (d *ValueDiff) Pretty(indent string) string {
+	if d == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	switch d.Kind {
+	case diffChars:
+		// Expected and actual on their own lines, with the differing characters highlighted
+		var expected, actual strings.Builder
+		for _, segment := range d.Segments {
+			switch segment.Op {
+			case diffExpected:
+				expected.WriteString(colorBold + colorGreen + segment.Text + colorReset)
+			case diffActual:
+				actual.WriteString(colorBold + colorRed + segment.Text + colorReset)
+			default:
+				expected.WriteString(segment.Text)
+				actual.WriteString(segment.Text)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("%s%s- Expected:%s %s\n", indent, colorGreen, colorReset, expected.String()))
+		sb.WriteString(fmt.Sprintf("%s%s+ Actual:%s   %s\n", indent, colorRed, colorReset, actual.String()))
+
+	case diffLines:
+		sb.WriteString(fmt.Sprintf("%s%s- Expected%s  %s+ Actual%s\n", indent, colorGreen, colorReset, colorRed, colorReset))
+		for _, segment := range collapseContext(d.Segments) {
+			switch segment.Op {
+			case diffExpected:
+				sb.WriteString(fmt.Sprintf("%s%s- %s%s\n", indent, colorGreen, segment.Text, colorReset))
+			case diffActual:
+				sb.WriteString(fmt.Sprintf("%s%s+ %s%s\n", indent, colorRed, segment.Text, colorReset))
+			default:
+				sb.WriteString(fmt.Sprintf("%s%s  %s%s\n", indent, colorGray, segment.Text, colorReset))
+			}
+		}
+
+	default:
+		for _, p := range d.Paths {
+			sb.WriteString(fmt.Sprintf("%s%s%s:%s ", indent, colorCyan, p.DisplayPath(), colorReset))
+			switch {
+			case p.Missing:
+				sb.WriteString(fmt.Sprintf("%smissing%s (expected %s%s%s)\n", colorRed, colorReset, colorGreen, diffValue(p.Expected), colorReset))
+			case p.Extra:
+				sb.WriteString(fmt.Sprintf("%sunexpected %s%s\n", colorRed, diffValue(p.Actual), colorReset))
+			default:
+				sb.WriteString(fmt.Sprintf("expected %s%s%s, got %s%s%s\n",
+					colorGreen, diffValue(p.Expected), colorReset, colorRed, diffValue(p.Actual), colorReset))
+			}
+		}
+		if d.Omitted > 0 {
+			sb.WriteString(fmt.Sprintf("%s%s... and %d more difference(s)%s\n", indent, colorGray, d.Omitted, colorReset))
+		}
+	}
+	return sb.String()
+}
+
+// DisplayPath names the location of a difference ("(root)" for the value itself)
+func (p pathDifference) DisplayPath() string {
+	if p.Path == "" {
+		return "(root)"
+	}
+	return p.Path
+}
+
+func (p pathDifference) String() string {
+	switch {
+	case p.Missing:
+		return fmt.Sprintf("%s: missing (expected %s)", p.DisplayPath(), diffValue(p.Expected))
+	case p.Extra:
+		return fmt.Sprintf("%s: unexpected %s", p.DisplayPath(), diffValue(p.Actual))
+	default:
+		return fmt.Sprintf("%s: expected %s, got %s", p.DisplayPath(), diffValue(p.Expected), diffValue(p.Actual))
+	}
+}
+
+// collectPathDiffs walks two structures and records every mismatching path
+func collectPathDiffs(path string, actual, expected interface{}, diffs *[]pathDifference) {
+	actualMap, actualIsMap := actual.(map[string]interface{})
+	expectedMap, expectedIsMap := expected.(map[string]interface{})
+	if actualIsMap && expectedIsMap {
+		keys := make(map[string]bool)
+		for key := range actualMap {
+			keys[key] = true
+		}
+		for key := range expectedMap {
+			keys[key] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+
+		for _, key := range sorted {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			actualValue, inActual := actualMap[key]
+			expectedValue, inExpected := expectedMap[key]
+			switch {
+			case !inActual:
+				*diffs = append(*diffs, pathDifference{Path: child, Expected: expectedValue, Missing: true})
+			case !inExpected:
+				*diffs = append(*diffs, pathDifference{Path: child, Actual: actualValue, Extra: true})
+			default:
+				collectPathDiffs(child, actualValue, expectedValue, diffs)
+			}
+		}
+		return
+	}
+
+	actualList, actualIsList := actual.([]interface{})
+	expectedList, expectedIsList := expected.([]interface{})
+	if actualIsList && expectedIsList {
+		for i := 0; i < len(actualList) || i < len(expectedList); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(actualList):
+				*diffs = append(*diffs, pathDifference{Path: child, Expected: expectedList[i], Missing: true})
+			case i >= len(expectedList):
+				*diffs = append(*diffs, pathDifference{Path: child, Actual: actualList[i], Extra: true})
+			default:
+				collectPathDiffs(child, actualList[i], expectedList[i], diffs)
+			}
+		}
+		return
+	}
+
+	if equal, _ := compare(actual, expected, "=="); !equal || isStructure(actual) != isStructure(expected) {
+		*diffs = append(*diffs, pathDifference{Path: path, Actual: actual, Expected: expected})
+	}
+}
+
+// isStructure reports whether v is a map or list (as decoded from JSON/YAML)
+func isStructure(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// diffValue renders a value inside a diff: JSON, so strings are quoted
+func diffValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// diffCharacters diffs two single-line strings rune by rune, falling back to
+// replacing the whole differing middle when they have little in common
+func diffCharacters(expected, actual string) []diffSegment {
+	expectedRunes := strings.Split(expected, "")
+	actualRunes := strings.Split(actual, "")
+	segments := mergeSegments(diffSequences(expectedRunes, actualRunes))
+
+	common, longest := 0, len(expectedRunes)
+	if len(actualRunes) > longest {
+		longest = len(actualRunes)
+	}
+	for _, segment := range segments {
+		if segment.Op == diffEqual {
+			common += len([]rune(segment.Text))
+		}
+	}
+	if longest > 0 && float64(common)/float64(longest) < diffMinMatch {
+		return mergeSegments(trimmedDiff(expectedRunes, actualRunes))
+	}
+	return segments
+}
+
+// diffSequences computes a minimal diff of two sequences using their longest common subsequence
+func diffSequences(expected, actual []string) []diffSegment {
+	if float64(len(expected))*float64(len(actual)) > diffMaxCells {
+		return trimmedDiff(expected, actual)
+	}
+
+	// lcs[i][j] = length of the LCS of expected[i:] and actual[j:]
+	lcs := make([][]int, len(expected)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(actual)+1)
+	}
+	for i := len(expected) - 1; i >= 0; i-- {
+		for j := len(actual) - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var segments []diffSegment
+	i, j := 0, 0
+	for i < len(expected) && j < len(actual) {
+		switch {
+		case expected[i] == actual[j]:
+			segments = append(segments, diffSegment{Op: diffEqual, Text: expected[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			segments = append(segments, diffSegment{Op: diffExpected, Text: expected[i]})
+			i++
+		default:
+			segments = append(segments, diffSegment{Op: diffActual, Text: actual[j]})
+			j++
+		}
+	}
+	for ; i < len(expected); i++ {
+		segments = append(segments, diffSegment{Op: diffExpected, Text: expected[i]})
+	}
+	for ; j < len(actual); j++ {
+		segments = append(segments, diffSegment{Op: diffActual, Text: actual[j]})
+	}
+	return segments
+}
+
+// trimmedDiff keeps the common prefix and suffix and marks everything in between as changed
+func trimmedDiff(expected, actual []string) []diffSegment {
+	prefix := 0
+	for prefix < len(expected) && prefix < len(actual) && expected[prefix] == actual[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(expected)-prefix && suffix < len(actual)-prefix &&
+		expected[len(expected)-1-suffix] == actual[len(actual)-1-suffix] {
+		suffix++
+	}
+
+	var segments []diffSegment
+	for _, item := range expected[:prefix] {
+		segments = append(segments, diffSegment{Op: diffEqual, Text: item})
+	}
+	for _, item := range expected[prefix : len(expected)-suffix] {
+		segments = append(segments, diffSegment{Op: diffExpected, Text: item})
+	}
+	for _, item := range actual[prefix : len(actual)-suffix] {
+		segments = append(segments, diffSegment{Op: diffActual, Text: item})
+	}
+	for _, item := range expected[len(expected)-suffix:] {
+		segments = append(segments, diffSegment{Op: diffEqual, Text: item})
+	}
+	return segments
+}
+
+// mergeSegments joins neighbouring segments of the same kind (used for character diffs)
+func mergeSegments(segments []diffSegment) []diffSegment {
+	var merged []diffSegment
+	for _, segment := range segments {
+		if n := len(merged); n > 0 && merged[n-1].Op == segment.Op {
+			merged[n-1].Text += segment.Text
+			continue
+		}
+		merged = append(merged, segment)
+	}
+	return merged
+}
+
+// collapseContext drops unchanged lines far away from any change
+func collapseContext(segments []diffSegment) []diffSegment {
+	keep := make([]bool, len(segments))
+	for i, segment := range segments {
+		if segment.Op == diffEqual {
+			continue
+		}
+		for j := i - diffContext; j <= i+diffContext; j++ {
+			if j >= 0 && j < len(segments) {
+				keep[j] = true
+			}
+		}
+	}
+
+	var collapsed []diffSegment
+	skipped := 0
+	flush := func() {
+		if skipped > 0 {
+			collapsed = append(collapsed, diffSegment{Op: diffEqual, Text: fmt.Sprintf("... (%d unchanged line(s))", skipped)})
+			skipped = 0
+		}
+	}
+	for i, segment := range segments {
+		if !keep[i] {
+			skipped++
+			continue
+		}
+		flush()
+		collapsed = append(collapsed, segment)
+	}
+	flush()
+	return collapsed
+}
+
+// splitLines splits text into lines, ignoring a single trailing newline
+func splitLines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestDiffCharacters(t *testing.T) {
+	diff := diffValues("hello world", "hello word")
+	if diff == nil || diff.Kind != diffChars {
+		t.Fatalf("expected a char diff, got %+v", diff)
+	}
+
+	want := []string{"hello wor{+l+}d"}
+	if got := diff.Compact(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compact() = %q, want %q", got, want)
+	}
+}
+
+func TestDiffCharactersWithLittleInCommon(t *testing.T) {
+	diff := diffValues("apple", "orange")
+	want := []string{"[-orang-]{+appl+}e"}
+	if got := diff.Compact(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compact() = %q, want %q", got, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	expected := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	actual := "a\nb\nc\nd\ne\nF\ng\nh\n"
+
+	diff := diffValues(actual, expected)
+	if diff == nil || diff.Kind != diffLines {
+		t.Fatalf("expected a line diff, got %+v", diff)
+	}
+
+	want := []string{"  ... (3 unchanged line(s))", "  d", "  e", "- f", "+ F", "  g", "  h"}
+	if got := diff.Compact(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compact() = %q, want %q", got, want)
+	}
+}
+
+func TestDiffPaths(t *testing.T) {
+	actual := map[string]interface{}{
+		"name": "Bob",
+		"tags": []interface{}{"a", "b"},
+		"age":  30.0,
+		"role": "admin",
+	}
+	expected := map[string]interface{}{
+		"name":  "Alice",
+		"tags":  []interface{}{"a", "b", "c"},
+		"age":   30,
+		"email": "alice@example.com",
+	}
+
+	diff := diffValues(actual, expected)
+	if diff == nil || diff.Kind != diffPaths {
+		t.Fatalf("expected a path diff, got %+v", diff)
+	}
+
+	want := []string{
+		`email: missing (expected "alice@example.com")`,
+		`name: expected "Alice", got "Bob"`,
+		`role: unexpected "admin"`,
+		`tags[2]: missing (expected "c")`,
+	}
+	if got := diff.Compact(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compact() = %q, want %q", got, want)
+	}
+}
+
+func TestResultDiffOnlyForEquality(t *testing.T) {
+	result := parser.TestResult{Actual: "hello", Expected: "contains bye", Operator: "contains"}
+	if diff := newResultDiff(result); diff != nil {
+		t.Errorf("expected no diff for contains, got %+v", diff)
+	}
+
+	result = parser.TestResult{Actual: 4.0, Expected: 5.0, Operator: "=="}
+	if diff := newResultDiff(result); diff != nil {
+		t.Errorf("expected no diff for numbers, got %+v", diff)
+	}
+
+	result = parser.TestResult{Actual: "hello", Expected: "help", Operator: "=="}
+	if pretty := newResultDiff(result).Pretty(""); !strings.Contains(pretty, "Expected") || !strings.Contains(pretty, "Actual") {
+		t.Errorf("Pretty() missing labels:\n%s", pretty)
+	}
+}
@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode identifies why a test failed. Codes are part of every output format and
+// stay stable across releases, so tools and agents can match on them instead of on
+// error messages.
+type ErrorCode string
+
+const (
+	CodeAssertMismatch     ErrorCode = "VYB_ASSERT_MISMATCH"     // An expectation was not met
+	CodeUndefinedVar       ErrorCode = "VYB_UNDEFINED_VAR"       // A variable was used before it was set
+	CodeUnknownFunc        ErrorCode = "VYB_UNKNOWN_FUNC"        // Neither a built-in nor exported by a module
+	CodeUnknownProperty    ErrorCode = "VYB_UNKNOWN_PROPERTY"    // An object has no such property
+	CodeTypeMismatch       ErrorCode = "VYB_TYPE_MISMATCH"       // A value has the wrong type for an operation
+	CodeInvalidArgs        ErrorCode = "VYB_INVALID_ARGS"        // A built-in got the wrong number or kind of arguments
+	CodeDivisionByZero     ErrorCode = "VYB_DIVISION_BY_ZERO"    // divide() by zero
+	CodeInvalidStatement   ErrorCode = "VYB_INVALID_STATEMENT"   // A 'when' step is not 'var = expr'
+	CodeInvalidExpression  ErrorCode = "VYB_INVALID_EXPRESSION"  // An expression could not be parsed
+	CodeInvalidExpectation ErrorCode = "VYB_INVALID_EXPECTATION" // A 'then' step is not 'expect: <expr> <op> <expr>'
+	CodeExternalError      ErrorCode = "VYB_EXTERNAL_ERROR"      // The function under test threw
+	CodeUnserializable     ErrorCode = "VYB_UNSERIALIZABLE"      // The function returned a value JSON can't represent
+	CodeModuleNotFound     ErrorCode = "VYB_MODULE_NOT_FOUND"    // A configured module could not be loaded
+	CodeBridgeCrash        ErrorCode = "VYB_BRIDGE_CRASH"        // The language runtime exited without answering
+	CodeRuntimeError       ErrorCode = "VYB_RUNTIME_ERROR"       // Any other error
+)
+
+// errorCodeInfo documents an error code for reports that explain their codes (SARIF rules)
+type errorCodeInfo struct {
+	Code        ErrorCode
+	Name        string
+	Description string
+	Help        string
+}
+
+// errorCodes lists every code in a fixed order
+var errorCodes = []errorCodeInfo{
+	{CodeAssertMismatch, "AssertMismatch", "An expectation was not met",
+		"The actual value differs from the expected one. Check the implementation, or the test if its confidence is low."},
+	{CodeUndefinedVar, "UndefinedVariable", "A test uses a variable that was never set",
+		"Define the variable in 'given' or assign it in a 'when' step before using it."},
+	{CodeUnknownFunc, "UnknownFunction", "A test calls a function that is neither built in nor exported",
+		"Export the function from a module listed in vyb.config.yaml, or fix its name."},
+	{CodeUnknownProperty, "UnknownProperty", "A test reads a property the object doesn't have",
+		"Check the property name against the object the function returns."},
+	{CodeTypeMismatch, "TypeMismatch", "A value has the wrong type for an operation",
+		"Check the types returned by the code under test and used in the expectation."},
+	{CodeInvalidArgs, "InvalidArguments", "A built-in function got invalid arguments",
+		"Check the number and types of the arguments."},
+	{CodeDivisionByZero, "DivisionByZero", "A division by zero",
+		"Guard against zero before dividing, and test that edge case separately."},
+	{CodeInvalidStatement, "InvalidStatement", "A 'when' step could not be parsed",
+		"Write 'when' steps as 'var = expr'."},
+	{CodeInvalidExpression, "InvalidExpression", "An expression could not be parsed",
+		"Check the parentheses and arguments of function calls."},
+	{CodeInvalidExpectation, "InvalidExpectation", "A 'then' step could not be parsed",
+		"Write 'then' steps as 'expect: <expr> <op> <expr>'."},
+	{CodeExternalError, "ExternalFunctionError", "A function under test threw an error",
+		"The implementation raised an exception; its location is reported when the bridge captured a stack trace."},
+	{CodeUnserializable, "UnserializableResult", "A function returned a value that can't be sent as JSON",
+		"Return plain data (objects, lists, numbers, strings, booleans) instead of class instances."},
+	{CodeModuleNotFound, "ModuleNotFound", "A configured module could not be loaded",
+		"Check the module paths in vyb.config.yaml and build TypeScript before running."},
+	{CodeBridgeCrash, "BridgeCrash", "The language bridge failed to run",
+		"The runtime exited without answering, e.g. a syntax error in a module or a missing interpreter."},
+	{CodeRuntimeError, "RuntimeError", "A test step failed to evaluate",
+		"See the message for details."},
+}
+
+// CodedError is an error with a stable code
+type CodedError struct {
+	Code        ErrorCode
+	Message     string
+	Err         error    // Underlying error, if any
+	Suggestions []string // Names that were probably meant (undefined variables, unknown functions)
+}
+
+func (e *CodedError) Error() string {
+	return e.Message
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode returns the error's code
+func (e *CodedError) ErrorCode() ErrorCode {
+	return e.Code
+}
+
+// newError creates a coded error with a formatted message
+func newError(code ErrorCode, format string, args ...interface{}) *CodedError {
+	return &CodedError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// wrapError gives err a code, keeping it available to errors.As
+func wrapError(code ErrorCode, err error, format string, args ...interface{}) *CodedError {
+	return &CodedError{Code: code, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// errorSuggestions returns the "did you mean" names attached to err, if any
+func errorSuggestions(err error) []string {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return coded.Suggestions
+	}
+	return nil
+}
+
+// errorCode returns the code of the first coded error in err's chain
+func errorCode(err error) ErrorCode {
+	var coded interface{ ErrorCode() ErrorCode }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return CodeRuntimeError
+}
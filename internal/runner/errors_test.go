@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// staticBridge answers every call with the same result or error
+type staticBridge struct {
+	value interface{}
+	err   error
+}
+
+func (b *staticBridge) Call(functionName string, args []interface{}) (interface{}, error) {
+	return b.value, b.err
+}
+
+func TestRunTestReportsCodeAndStep(t *testing.T) {
+	tests := []struct {
+		name     string
+		test     *parser.Test
+		bridge   Bridge
+		code     ErrorCode
+		step     string
+		index    int
+		function string // Failed external call
+	}{
+		{
+			name: "mismatch",
+			test: &parser.Test{When: []string{"x = add(1, 1)"}, Then: []string{"expect: x == 2", "expect: x < 1"}},
+			code: CodeAssertMismatch, step: "then", index: 1,
+		},
+		{
+			name: "undefined variable",
+			test: &parser.Test{When: []string{"x = 1", "y = add(x, z)"}, Then: []string{"expect: y == 1"}},
+			code: CodeUndefinedVar, step: "when", index: 1,
+		},
+		{
+			name: "unknown function",
+			test: &parser.Test{When: []string{"x = nope()"}, Then: []string{"expect: x == 1"}},
+			code: CodeUnknownFunc, step: "when", index: 0,
+		},
+		{
+			name: "invalid expectation",
+			test: &parser.Test{When: []string{"x = 1"}, Then: []string{"x is 1"}},
+			code: CodeInvalidExpectation, step: "then", index: 0,
+		},
+		{
+			name:   "function threw",
+			test:   &parser.Test{When: []string{"x = total()"}, Then: []string{"expect: x == 1"}},
+			bridge: &staticBridge{err: &BridgeError{Code: CodeExternalError, Message: "boom"}},
+			code:   CodeExternalError, step: "when", index: 0, function: "total",
+		},
+		{
+			name:   "bridge crashed",
+			test:   &parser.Test{When: []string{"x = total()"}, Then: []string{"expect: x == 1"}},
+			bridge: &staticBridge{err: bridgeCrash(fmt.Errorf("exit status 1"), []byte("SyntaxError"), "node execution failed")},
+			code:   CodeBridgeCrash, step: "when", index: 0, function: "total",
+		},
+		{
+			name:   "uncoded bridge error",
+			test:   &parser.Test{When: []string{"x = total()"}, Then: []string{"expect: x == 1"}},
+			bridge: &staticBridge{err: fmt.Errorf("timed out")},
+			code:   CodeRuntimeError, step: "when", index: 0, function: "total",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runTest(tt.test, tt.bridge, nil)
+			if result.Passed {
+				t.Fatal("expected the test to fail")
+			}
+			if result.Code != string(tt.code) || result.Step != tt.step || result.StepIndex != tt.index {
+				t.Errorf("got %s at %s[%d], want %s at %s[%d]", result.Code, result.Step, result.StepIndex, tt.code, tt.step, tt.index)
+			}
+			if result.Function != tt.function {
+				t.Errorf("Function = %q, want %q", result.Function, tt.function)
+			}
+		})
+	}
+}
+
+func TestBridgeErrorCodes(t *testing.T) {
+	tests := []struct {
+		kind string
+		code ErrorCode
+	}{
+		{"", CodeExternalError},
+		{"not_found", CodeUnknownFunc},
+		{"unserializable", CodeUnserializable},
+	}
+	for _, tt := range tests {
+		_, err := bridgeResponse{Error: "boom", Kind: tt.kind}.value()
+		if got := errorCode(err); got != tt.code {
+			t.Errorf("kind %q: code = %s, want %s", tt.kind, got, tt.code)
+		}
+	}
+
+	crash := bridgeCrash(fmt.Errorf("exit status 1"), []byte("Error: Cannot find module './cart'"), "node execution failed")
+	if got := errorCode(fmt.Errorf("external function total() failed: %w", crash)); got != CodeModuleNotFound {
+		t.Errorf("missing module: code = %s, want %s", got, CodeModuleNotFound)
+	}
+}
+
+func TestGenerateHintsUsesCodes(t *testing.T) {
+	// The expectation text contains '<', which must not produce the numeric comparison hint
+	result := parser.TestResult{
+		Error:    "Expectation failed: expect: html == '<b>'",
+		Code:     string(CodeAssertMismatch),
+		Operator: "==",
+	}
+	hints := strings.Join(generateHints(&parser.Test{}, result), "\n")
+	if !strings.Contains(hints, "equality check failed") {
+		t.Errorf("missing equality hint:\n%s", hints)
+	}
+	if strings.Contains(hints, "numeric comparison") {
+		t.Errorf("unexpected numeric hint:\n%s", hints)
+	}
+
+	// A message that merely mentions an undefined variable is not an undefined variable error
+	result = parser.TestResult{
+		Error: "Failed to execute statement 'x = check()': external function error: undefined variable in config",
+		Code:  string(CodeExternalError),
+	}
+	hints = strings.Join(generateHints(&parser.Test{}, result), "\n")
+	if strings.Contains(hints, "'given' block") {
+		t.Errorf("hints matched on message text:\n%s", hints)
+	}
+}
+
+func TestErrorCodesAreDocumented(t *testing.T) {
+	seen := make(map[ErrorCode]bool)
+	for _, info := range errorCodes {
+		if seen[info.Code] {
+			t.Errorf("%s listed twice", info.Code)
+		}
+		seen[info.Code] = true
+		if !strings.HasPrefix(string(info.Code), "VYB_") || info.Description == "" {
+			t.Errorf("%s: incomplete entry", info.Code)
+		}
+	}
+}
@@ -1,47 +1,386 @@
 package runner
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/vybtest/vyb/internal/parser"
+	"gopkg.in/yaml.v3"
 )
 
-// formatTestCode converts a Test struct back to YAML format for display
-func formatTestCode(test *parser.Test) string {
-	var sb strings.Builder
+// testKeyOrder is the canonical order of a test's keys; other keys follow in the order
+// they were written
+var testKeyOrder = []string{"confidence", "tags", "given", "when", "then"}
 
-	// Test name
-	sb.WriteString(fmt.Sprintf("\"%s\":\n", test.Name))
+// formatTestCode converts a Test struct back to YAML format for display, in the
+// layout vyb fmt writes
+func formatTestCode(test *parser.Test) string {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	addPair := func(key string, value *yaml.Node) {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	scalar := func(value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	}
+	list := func(values []string) *yaml.Node {
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, value := range values {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+		}
+		return seq
+	}
 
-	// Confidence
 	if test.Confidence > 0 {
-		sb.WriteString(fmt.Sprintf("  confidence: %.2f\n", test.Confidence))
+		addPair("confidence", scalar(strconv.FormatFloat(test.Confidence, 'f', -1, 64)))
+	}
+	if len(test.Tags) > 0 {
+		addPair("tags", list(test.Tags))
 	}
-
-	// Given block
 	if len(test.Given) > 0 {
-		sb.WriteString("  given:\n")
-		for key, value := range test.Given {
-			sb.WriteString(fmt.Sprintf("    %s: %v\n", key, value))
+		given := &yaml.Node{}
+		if err := given.Encode(test.Given); err == nil {
+			addPair("given", given)
 		}
 	}
-
-	// When block
 	if len(test.When) > 0 {
-		sb.WriteString("  when:\n")
-		for _, stmt := range test.When {
-			sb.WriteString(fmt.Sprintf("    - \"%s\"\n", stmt))
+		addPair("when", list(test.When))
+	}
+	if len(test.Then) > 0 {
+		addPair("then", list(test.Then))
+	}
+	if test.Retries != nil {
+		addPair("retries", scalar(strconv.Itoa(*test.Retries)))
+	}
+
+	name := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: test.Name}
+	canonicalTest(name, node)
+	root := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{name, node}}
+
+	data, err := encodeYAML(root)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Format rewrites the test files matching patterns into the canonical layout, or with
+// check only lists the files that aren't formatted. Returns an error when a file is not
+// formatted (check) or couldn't be formatted.
+func Format(patterns []string, check bool, out io.Writer) error {
+	var files []string
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches, err := findTestFiles(pattern)
+		if err != nil {
+			return fmt.Errorf("failed to find test files: %w", err)
+		}
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
 		}
 	}
 
-	// Then block
-	if len(test.Then) > 0 {
-		sb.WriteString("  then:\n")
-		for _, expectation := range test.Then {
-			sb.WriteString(fmt.Sprintf("    - \"%s\"\n", expectation))
+	if len(files) == 0 {
+		fmt.Fprintln(out, "No test files found matching:", strings.Join(patterns, " "))
+		return nil
+	}
+
+	unformatted, failed := 0, 0
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			fmt.Fprintf(out, "%s❌ %s: %v%s\n", colorRed, file, err, colorReset)
+			failed++
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(out, "%s❌ %s: %v%s\n", colorRed, file, err, colorReset)
+			failed++
+			continue
+		}
+
+		formatted, err := formatSource(file, data)
+		if err != nil {
+			fmt.Fprintf(out, "%s❌ %s: %v%s\n", colorRed, file, err, colorReset)
+			failed++
+			continue
+		}
+		if bytes.Equal(formatted, data) {
+			continue
+		}
+
+		unformatted++
+		if check {
+			fmt.Fprintf(out, "%s\n", file)
+			continue
+		}
+		if err := os.WriteFile(file, formatted, info.Mode().Perm()); err != nil {
+			fmt.Fprintf(out, "%s❌ %s: %v%s\n", colorRed, file, err, colorReset)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "Formatted %s\n", file)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to format %d file(s)", failed)
+	}
+	if check && unformatted > 0 {
+		return fmt.Errorf("%d file(s) not formatted (run 'vyb fmt' to fix)", unformatted)
+	}
+	return nil
+}
+
+// formatSource returns a test file in the canonical layout: tests keyed by name, keys
+// in testKeyOrder, names and steps double-quoted, other strings quoted only when they
+// must be (with double quotes), a blank line between tests. Comments are kept. Fails
+// rather than change what the tests do.
+func formatSource(file string, data []byte) ([]byte, error) {
+	before, err := parser.ParseBytes(file, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+
+	if mappingValue(root, "test") != nil {
+		if err := migrateLegacyTest(root); err != nil {
+			return nil, err
+		}
+	}
+
+	root.Style = 0
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if value.Kind == yaml.MappingNode && (mappingValue(value, "when") != nil || mappingValue(value, "then") != nil) {
+			canonicalTest(key, value)
+		}
+	}
+
+	formatted, err := encodeYAML(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := parser.ParseBytes(file, formatted)
+	if err != nil || !sameTests(before.Tests, after.Tests) {
+		return nil, fmt.Errorf("formatting would change the tests; the file was left as is")
+	}
+	return formatted, nil
+}
+
+// migrateLegacyTest turns a legacy file (a single test under "test:", named by its
+// "name" key) into a name-as-key file
+func migrateLegacyTest(root *yaml.Node) error {
+	if len(root.Content) != 2 {
+		return fmt.Errorf("cannot migrate the legacy format: keys other than 'test:' would become tests")
+	}
+
+	key, test := root.Content[0], root.Content[1]
+	if test.Kind != yaml.MappingNode {
+		return fmt.Errorf("cannot migrate the legacy format: 'test:' is not a mapping")
+	}
+
+	var name *yaml.Node
+	var rest []*yaml.Node
+	for i := 0; i+1 < len(test.Content); i += 2 {
+		if test.Content[i].Value == "name" {
+			name = test.Content[i+1]
+			name.HeadComment = joinComments(key.HeadComment, test.Content[i].HeadComment, name.HeadComment)
+			name.LineComment = joinComments(key.LineComment, test.Content[i].LineComment, name.LineComment)
+			continue
+		}
+		rest = append(rest, test.Content[i], test.Content[i+1])
+	}
+	if name == nil || name.Kind != yaml.ScalarNode || name.Value == "" {
+		return fmt.Errorf("cannot migrate the legacy format: the test has no name")
+	}
+
+	test.Content = rest
+	root.Content[0] = name
+	return nil
+}
+
+// canonicalTest orders and quotes a test's keys and values in place
+func canonicalTest(name, test *yaml.Node) {
+	name.Kind, name.Tag, name.Style = yaml.ScalarNode, "!!str", yaml.DoubleQuotedStyle
+	test.Style = 0
+	keepLineComment(name, test)
+
+	// Stable reorder: known keys first, in order, then the rest as written
+	rank := func(key string) int {
+		for i, known := range testKeyOrder {
+			if key == known {
+				return i
+			}
+		}
+		return len(testKeyOrder)
+	}
+	type pair struct{ key, value *yaml.Node }
+	var pairs []pair
+	for i := 0; i+1 < len(test.Content); i += 2 {
+		pairs = append(pairs, pair{test.Content[i], test.Content[i+1]})
+	}
+	for i := 1; i < len(pairs); i++ {
+		for j := i; j > 0 && rank(pairs[j].key.Value) < rank(pairs[j-1].key.Value); j-- {
+			pairs[j], pairs[j-1] = pairs[j-1], pairs[j]
+		}
+	}
+
+	test.Content = test.Content[:0]
+	for _, p := range pairs {
+		p.key.Style = 0
+		switch p.key.Value {
+		case "when", "then":
+			if p.value.Kind == yaml.SequenceNode {
+				p.value.Style = 0
+				for _, step := range p.value.Content {
+					if step.Kind == yaml.ScalarNode {
+						step.Tag, step.Style = "!!str", yaml.DoubleQuotedStyle
+					}
+				}
+			}
+		case "tags":
+			canonicalValue(p.value)
+			if p.value.Kind == yaml.SequenceNode {
+				p.value.Style = yaml.FlowStyle
+			}
+		default:
+			canonicalValue(p.value)
+		}
+		keepLineComment(p.key, p.value)
+		test.Content = append(test.Content, p.key, p.value)
+	}
+}
+
+// canonicalValue lays out a value: mappings as blocks, lists of plain values inline,
+// strings quoted only when they must be
+func canonicalValue(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		node.Style = 0
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			node.Content[i].Style = quoteStyle(node.Content[i])
+			canonicalValue(node.Content[i+1])
+			keepLineComment(node.Content[i], node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		node.Style = yaml.FlowStyle
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				node.Style = 0
+			}
+			canonicalValue(item)
+		}
+		for _, item := range node.Content {
+			if len(item.Content) > 0 {
+				keepLineComment(item.Content[0], item)
+			}
+		}
+	case yaml.ScalarNode:
+		node.Style = quoteStyle(node)
+	}
+}
+
+// keepLineComment moves the line comment of a list or mapping laid out as a block onto
+// the node starting its line (its key, or its first entry in a list). The comment was
+// written after a flow collection, and the encoder would otherwise move it to another
+// line.
+func keepLineComment(first, value *yaml.Node) {
+	if value.LineComment == "" || value.Style&yaml.FlowStyle != 0 ||
+		(value.Kind != yaml.SequenceNode && value.Kind != yaml.MappingNode) {
+		return
+	}
+	if first.LineComment != "" {
+		first.LineComment += " " + value.LineComment
+	} else {
+		first.LineComment = value.LineComment
+	}
+	value.LineComment = ""
+}
+
+// quoteStyle returns the style for a scalar: plain when YAML reads it back unchanged,
+// double quotes otherwise, and a literal block for multi-line strings
+func quoteStyle(node *yaml.Node) yaml.Style {
+	if node.ShortTag() != "!!str" {
+		return 0
+	}
+	if strings.Contains(node.Value, "\n") {
+		return yaml.LiteralStyle
+	}
+
+	plain, err := yaml.Marshal(node.Value)
+	if err != nil || strings.ContainsAny(string(plain[:1]), `'"|>`) {
+		return yaml.DoubleQuotedStyle
+	}
+	return 0
+}
+
+// encodeYAML writes a document with 2-space indentation and a blank line between
+// top-level entries (before their comments)
+func encodeYAML(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	encoder.Close()
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	seenEntry := false
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// A top-level entry: separate it, with the comments right above it, from the
+		// previous one
+		start := i
+		for start > 0 && strings.HasPrefix(lines[start-1], "#") {
+			start--
+		}
+		if seenEntry && start > 0 && lines[start-1] != "" {
+			lines[start] = "\n" + lines[start]
+		}
+		seenEntry = true
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// sameTests reports whether formatting kept the tests' meaning (lines may move)
+func sameTests(before, after []parser.Test) bool {
+	if len(before) != len(after) {
+		return false
+	}
+	for i := range before {
+		a, b := before[i], after[i]
+		a.Line, b.Line = 0, 0
+		if !reflect.DeepEqual(a, b) {
+			return false
 		}
 	}
+	return true
+}
 
-	return sb.String()
+// joinComments joins the non-empty comments, one per line
+func joinComments(comments ...string) string {
+	var parts []string
+	for _, comment := range comments {
+		if comment != "" {
+			parts = append(parts, comment)
+		}
+	}
+	return strings.Join(parts, "\n")
 }
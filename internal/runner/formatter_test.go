@@ -0,0 +1,222 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestFormatSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "key order and quoting",
+			input: `adds:
+  then:
+    - 'expect: x == 3'
+  when:
+    - x = add(1, 2)
+  given:
+    a: "plain"
+    b: 'a: b'
+    c: "2"
+    items: ['a', b]
+  tags: ['math']
+  confidence: 0.9
+`,
+			want: `"adds":
+  confidence: 0.9
+  tags: [math]
+  given:
+    a: plain
+    b: "a: b"
+    c: "2"
+    items: [a, b]
+  when:
+    - "x = add(1, 2)"
+  then:
+    - "expect: x == 3"
+`,
+		},
+		{
+			name: "comments and blank lines",
+			input: `# Calculator tests
+adds: # the basics
+  when:
+    - x = add(1, 2) # sum
+  then:
+    - "expect: x == 3"
+# Quotes in names are escaped
+'says "hi"':
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 2"
+`,
+			want: `# Calculator tests
+"adds": # the basics
+  when:
+    - "x = add(1, 2)" # sum
+  then:
+    - "expect: x == 3"
+
+# Quotes in names are escaped
+"says \"hi\"":
+  when:
+    - "x = add(1, 1)"
+  then:
+    - "expect: x == 2"
+`,
+		},
+		{
+			name: "legacy format",
+			input: `# Old style
+test:
+  name: adds numbers
+  confidence: 0.8
+  when:
+    - "x = add(2, 2)"
+  then:
+    - "expect: x == 4"
+`,
+			want: `# Old style
+"adds numbers":
+  confidence: 0.8
+  when:
+    - "x = add(2, 2)"
+  then:
+    - "expect: x == 4"
+`,
+		},
+		{
+			name: "comments on flow lists",
+			input: `adds:
+  when: ["x = add(1, 1)", "y = add(x, 1)"] # two steps
+  then: ["expect: y == 3"] # the check
+  given: {a: 1, items: [{id: 1}, {id: 2}]} # setup
+  tags: [math, fast] # stays inline
+`,
+			want: `"adds":
+  tags: [math, fast] # stays inline
+  given: # setup
+    a: 1
+    items:
+      - id: 1
+      - id: 2
+  when: # two steps
+    - "x = add(1, 1)"
+    - "y = add(x, 1)"
+  then: # the check
+    - "expect: y == 3"
+`,
+		},
+		{
+			name: "legacy format with comments on flow lists",
+			input: `test:
+  name: adds # the name
+  when: ["x = add(2, 2)"] # one step
+  then: ["expect: x == 4"] # the check
+`,
+			want: `"adds": # the name
+  when: # one step
+    - "x = add(2, 2)"
+  then: # the check
+    - "expect: x == 4"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatSource("calc.js.vyb", []byte(tt.input))
+			if err != nil {
+				t.Fatalf("formatSource failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Got:\n%s\nWant:\n%s", got, tt.want)
+			}
+
+			// Formatting is idempotent
+			again, err := formatSource("calc.js.vyb", got)
+			if err != nil || !bytes.Equal(again, got) {
+				t.Errorf("Formatting twice changed the file:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFormatSourceRejectsBrokenFiles(t *testing.T) {
+	if _, err := formatSource("broken.vyb", []byte("adds: [\n")); err == nil {
+		t.Error("Expected an error for a file that doesn't parse")
+	}
+	legacy := "test:\n  name: adds\n  when: [x = add(1, 1)]\n  then: [\"expect: x == 2\"]\nother: 1\n"
+	if _, err := formatSource("legacy.vyb", []byte(legacy)); err == nil {
+		t.Error("Expected an error for a legacy file with extra top-level keys")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	formatted := "\"adds\":\n  when:\n    - \"x = add(1, 2)\"\n  then:\n    - \"expect: x == 3\"\n"
+	os.WriteFile("good.js.vyb", []byte(formatted), 0644)
+	os.WriteFile("messy.js.vyb", []byte("adds:\n  then: ['expect: x == 3']\n  when: [x = add(1 + 2)]\n"), 0644)
+
+	var out bytes.Buffer
+	err := Format([]string{"."}, true, &out)
+	if err == nil || !strings.Contains(err.Error(), "1 file(s) not formatted") {
+		t.Errorf("Expected --check to fail for the messy file, got %v", err)
+	}
+	if !strings.Contains(out.String(), "messy.js.vyb") || strings.Contains(out.String(), "good.js.vyb") {
+		t.Errorf("Expected only the messy file listed, got:\n%s", out.String())
+	}
+	if data, _ := os.ReadFile("messy.js.vyb"); strings.Contains(string(data), "\"adds\"") {
+		t.Error("--check must not rewrite files")
+	}
+
+	out.Reset()
+	if err := Format([]string{"."}, false, &out); err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Formatted messy.js.vyb") {
+		t.Errorf("Expected the messy file to be reported, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := Format([]string{"."}, true, &out); err != nil {
+		t.Errorf("Expected --check to pass after formatting, got %v:\n%s", err, out.String())
+	}
+}
+
+func TestFormatTestCode(t *testing.T) {
+	test := &parser.Test{
+		Name:       `says "hi"`,
+		Confidence: 0.9,
+		Given:      map[string]interface{}{"b": 2, "a": "x"},
+		When:       []string{`greeting = greet("hi")`},
+		Then:       []string{`expect: greeting == "hi"`},
+	}
+
+	want := `"says \"hi\"":
+  confidence: 0.9
+  given:
+    a: x
+    b: 2
+  when:
+    - "greeting = greet(\"hi\")"
+  then:
+    - "expect: greeting == \"hi\""
+`
+	if got := formatTestCode(test); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
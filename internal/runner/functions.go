@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// callPattern matches function calls in when/then statements: name(
+var callPattern = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+
+// exportPatterns match function definitions and exports across supported runtimes
+var exportPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][A-Za-z0-9_$]*)\s*\(`),                                       // JS/TS function declarations
+	regexp.MustCompile(`exports\.([A-Za-z_$][A-Za-z0-9_$]*)\s*=`),                                                                                   // CommonJS exports
+	regexp.MustCompile(`(?m)^\s*export\s+(?:const|let|var)\s+([A-Za-z_$][A-Za-z0-9_$]*)`),                                                           // ES module exports
+	regexp.MustCompile(`(?m)^\s*(?:const|let|var)\s+([A-Za-z_$][A-Za-z0-9_$]*)\s*=\s*(?:async\s+)?(?:function\b|\(|[A-Za-z_$][A-Za-z0-9_$]*\s*=>)`), // JS/TS function expressions
+	regexp.MustCompile(`(?m)^\s*(?:async\s+)?def\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(`),                                                                  // Python
+	regexp.MustCompile(`(?m)^\s*(?:local\s+)?function\s+[A-Za-z_][A-Za-z0-9_]*[.:]([A-Za-z_][A-Za-z0-9_]*)\s*\(`),                                   // Lua: function M.name(
+	regexp.MustCompile(`(?m)^\s*[A-Za-z_][A-Za-z0-9_]*\.([A-Za-z_][A-Za-z0-9_]*)\s*=\s*function`),                                                   // Lua: M.name = function
+}
+
+// exportListPatterns match lists of names exported or imported at once; each item is a
+// name, optionally renamed with "as" (or "key: value" in CommonJS objects)
+var exportListPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:\bexport|\bmodule\.exports\s*=)\s*\{([^}]*)\}`), // export { a, b as c } and module.exports = { a, b: c }
+	regexp.MustCompile(`(?m)^\s*from\s+\S+\s+import\s+\(([^)]*)\)`),        // Python: from x import (a, b)
+	regexp.MustCompile(`(?m)^\s*from\s+\S+\s+import\s+([^(*\n][^\n]*)$`),   // Python: from x import a, b as c
+}
+
+// listedNames returns the names a list of exports or imports makes available
+func listedNames(list string) []string {
+	var names []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if _, alias, ok := strings.Cut(item, " as "); ok {
+			item = alias
+		} else if key, _, ok := strings.Cut(item, ":"); ok {
+			item = key
+		}
+		if item = strings.TrimSpace(item); identifierPattern.MatchString(item) {
+			names = append(names, item)
+		}
+	}
+	return names
+}
+
+// calledFunctions returns the sorted, de-duplicated names of functions a test calls
+func calledFunctions(test *parser.Test) []string {
+	seen := make(map[string]bool)
+	var names []string
+
+	statements := append(append([]string{}, test.When...), test.Then...)
+	for _, stmt := range statements {
+		for _, match := range callPattern.FindAllStringSubmatch(stmt, -1) {
+			name := match[1]
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// moduleFunctions statically scans a module source file for the functions it defines or exports.
+// This is a best-effort scan used when starting a bridge would be too expensive; it can
+// miss functions (e.g. re-exported with "export *"), so a name it doesn't find may exist.
+func moduleFunctions(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, pattern := range exportPatterns {
+		for _, match := range pattern.FindAllSubmatch(data, -1) {
+			add(string(match[1]))
+		}
+	}
+	for _, pattern := range exportListPatterns {
+		for _, match := range pattern.FindAllSubmatch(data, -1) {
+			for _, name := range listedNames(string(match[1])) {
+				add(name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
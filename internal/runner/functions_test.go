@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestModuleFunctions(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{"function.js", "function total(items) {}\nasync function load() {}\n", []string{"load", "total"}},
+		{"arrow.js", "const square = (n) => n * n;\nlet cube = n => n ** 3\nvar half = function (n) { return n / 2 }\nmodule.exports = { square };\n", []string{"cube", "half", "square"}},
+		{"object.js", "module.exports = {\n  double: (n) => n * 2,\n  triple,\n}\n", []string{"double", "triple"}},
+		{"reexport.mjs", "export { parse, format as formatPrice } from './money.js'\nexport const tax = 0.2\n", []string{"formatPrice", "parse", "tax"}},
+		{"cart.py", "from .money import parse, format as format_price\nfrom .tax import (rate,\n    vat)\n\ndef total(items):\n    pass\n", []string{"format_price", "parse", "rate", "total", "vat"}},
+		{"cart.lua", "local M = {}\nfunction M.total(items) end\nM.count = function(items) end\nreturn M\n", []string{"count", "total"}},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		os.WriteFile(path, []byte(tt.source), 0644)
+		got, err := moduleFunctions(path)
+		if err != nil {
+			t.Fatalf("moduleFunctions(%s) failed: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("moduleFunctions(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
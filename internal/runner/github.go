@@ -0,0 +1,302 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// githubReporter emits GitHub Actions workflow commands: an annotation per failing
+// (or flaky) test and, when $GITHUB_STEP_SUMMARY is set, a Markdown job summary
+type githubReporter struct {
+	baseReporter
+	out         io.Writer
+	summaryPath string // $GITHUB_STEP_SUMMARY (empty = no job summary)
+	workspace   string // $GITHUB_WORKSPACE, annotation paths are relative to it
+	reports     []TestReport
+}
+
+func newGitHubReporter(out io.Writer) *githubReporter {
+	return &githubReporter{
+		out:         out,
+		summaryPath: os.Getenv("GITHUB_STEP_SUMMARY"),
+		workspace:   os.Getenv("GITHUB_WORKSPACE"),
+	}
+}
+
+func (r *githubReporter) FileStart(file string) {
+	fmt.Fprintf(r.out, "::group::%s\n", escapeWorkflowData(file))
+}
+
+func (r *githubReporter) TestEnd(report TestReport) {
+	r.reports = append(r.reports, report)
+	result := report.Result
+
+	switch report.Status {
+	case "pass":
+		fmt.Fprintf(r.out, "✅ %s (confidence %.2f)\n", result.Name, result.Confidence)
+	case "not_run":
+		// Counted in the final line and the job summary
+	case "flaky":
+		fmt.Fprintf(r.out, "⚠️ %s (flaky: passed on attempt %d)\n", result.Name, result.Attempts)
+		r.annotate("warning", report, fmt.Sprintf("Flaky test: passed on attempt %d", result.Attempts))
+	default:
+		fmt.Fprintf(r.out, "❌ %s\n", result.Name)
+		message := result.Error
+		if result.Code != "" {
+			message = result.Code + ": " + message
+		}
+		if result.Actual != nil || result.Expected != nil {
+			message += fmt.Sprintf("\nactual: %s\nexpected: %s", formatValue(result.Actual), formatValue(result.Expected))
+		}
+		r.annotate("error", report, message)
+	}
+}
+
+func (r *githubReporter) FileEnd(file string, summary FileSummary) {
+	fmt.Fprintln(r.out, "::endgroup::")
+}
+
+func (r *githubReporter) Notice(level NoticeLevel, message string) {
+	command := "notice"
+	switch level {
+	case NoticeWarning:
+		command = "warning"
+	case NoticeError:
+		command = "error"
+	}
+	fmt.Fprintf(r.out, "::%s::%s\n", command, escapeWorkflowData(strings.TrimSpace(message)))
+}
+
+func (r *githubReporter) Finish(summary TestSummary) error {
+	fmt.Fprintf(r.out, "Vyb: %d passed, %d failed, %d flaky, %d not run, %d total (confidence avg %.2f)\n",
+		summary.Passed, summary.Failed, summary.Flaky, summary.NotRun, summary.Total, summary.AverageConfidence)
+
+	if gate := summary.ConfidenceGate; gate != nil && !gate.Passed {
+		for _, failure := range gate.Failures {
+			fmt.Fprintf(r.out, "::error title=Confidence gate::%s\n", escapeWorkflowData(failure))
+		}
+		for _, test := range gate.LowConfidence {
+			r.annotateAt("warning", test.File, test.Line, test.Name, lowConfidenceMessage(test))
+		}
+	}
+
+	if r.summaryPath == "" {
+		return nil
+	}
+
+	// The summary file is shared by all steps of the job, so append
+	file, err := os.OpenFile(r.summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open job summary: %w", err)
+	}
+	defer file.Close()
+
+	_, err = io.WriteString(file, githubJobSummary(summary, r.reports))
+	return err
+}
+
+// annotate writes an ::error/::warning command pointing at the test's source position
+func (r *githubReporter) annotate(command string, report TestReport, message string) {
+	line := 0
+	if report.Test != nil {
+		line = report.Test.Line
+	}
+	r.annotateAt(command, report.File, line, report.Result.Name, message)
+}
+
+// annotateAt writes an ::error/::warning command for a position in a test file
+// (line 0 = the whole file)
+func (r *githubReporter) annotateAt(command, file string, line int, title, message string) {
+	properties := []string{"file=" + escapeWorkflowProperty(r.annotationPath(file))}
+	if line > 0 {
+		properties = append(properties, fmt.Sprintf("line=%d", line))
+	}
+	properties = append(properties, "title="+escapeWorkflowProperty(title))
+
+	fmt.Fprintf(r.out, "::%s %s::%s\n", command, strings.Join(properties, ","), escapeWorkflowData(message))
+}
+
+// annotationPath makes a test file path relative to the repository root, which is
+// what GitHub uses to place annotations on the diff
+func (r *githubReporter) annotationPath(file string) string {
+	if r.workspace == "" {
+		return filepath.ToSlash(file)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	rel, err := filepath.Rel(r.workspace, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// maxJobSummaryBytes keeps the job summary under GitHub's 1 MiB limit per step
+const maxJobSummaryBytes = 1000 * 1000
+
+// summaryTruncatedNote ends a job summary that was cut to fit maxJobSummaryBytes
+const summaryTruncatedNote = "_Truncated to fit GitHub's size limit: %d test row(s) and %d failure(s) left out. The log and annotations list every result._\n"
+
+// githubJobSummary renders the run as Markdown for the job summary page
+func githubJobSummary(summary TestSummary, reports []TestReport) string {
+	var sb strings.Builder
+
+	icon := "✅"
+	if summary.Failed > 0 {
+		icon = "❌"
+	}
+	sb.WriteString(fmt.Sprintf("## %s Vyb test results\n\n", icon))
+	sb.WriteString("| Passed | Failed | Flaky | Not run | Total | Time |\n")
+	sb.WriteString("|-------:|-------:|------:|--------:|------:|-----:|\n")
+	sb.WriteString(fmt.Sprintf("| %d | %d | %d | %d | %d | %.3fs |\n\n",
+		summary.Passed, summary.Failed, summary.Flaky, summary.NotRun, summary.Total, summary.Duration))
+
+	if summary.Passed+summary.Failed > 0 {
+		sb.WriteString(fmt.Sprintf("**Confidence:** avg %.2f, min %.2f, max %.2f\n\n",
+			summary.AverageConfidence, summary.MinConfidence, summary.MaxConfidence))
+	}
+	if gate := summary.ConfidenceGate; gate != nil && !gate.Passed {
+		sb.WriteString("**Confidence gate failed:**\n\n")
+		for _, failure := range gate.Failures {
+			sb.WriteString(fmt.Sprintf("- %s\n", failure))
+		}
+		sb.WriteString("\n")
+	}
+	if summary.Seed != 0 {
+		sb.WriteString(fmt.Sprintf("Shuffled with seed `%d` (reproduce with `--seed %d`)\n\n", summary.Seed, summary.Seed))
+	}
+	if summary.Shard != "" {
+		sb.WriteString(fmt.Sprintf("Shard `%s`\n\n", summary.Shard))
+	}
+
+	// GitHub rejects job summaries over 1 MiB. Failures matter most, so test rows are left
+	// out first to make room for them.
+	failures := failureSections(reports)
+	budget := maxJobSummaryBytes - len(summaryTruncatedNote) - 64 // Room for the note's counts and headings
+	failuresSize := 0
+	for _, section := range failures {
+		failuresSize += len(section)
+	}
+	omittedRows, omittedFailures := 0, 0
+
+	const tableHeader = "| File | Test | Status | Confidence | Time |\n|------|------|--------|-----------:|-----:|\n"
+	var table strings.Builder
+	for _, report := range reports {
+		row := fmt.Sprintf("| %s | %s | %s | %.2f | %.3fs |\n",
+			markdownCell(report.File), markdownCell(report.Result.Name), githubStatus(report.Status),
+			report.Result.Confidence, float64(report.Result.Duration)/1e9)
+		if omittedRows > 0 || sb.Len()+len(tableHeader)+table.Len()+len(row)+failuresSize > budget {
+			omittedRows++
+			continue
+		}
+		table.WriteString(row)
+	}
+	if table.Len() > 0 {
+		sb.WriteString(tableHeader + table.String() + "\n")
+	}
+
+	if len(failures) > 0 {
+		sb.WriteString("### Failures\n\n")
+		for _, section := range failures {
+			if omittedFailures > 0 || sb.Len()+len(section) > budget {
+				omittedFailures++
+				continue
+			}
+			sb.WriteString(section)
+		}
+	}
+
+	if omittedRows > 0 || omittedFailures > 0 {
+		sb.WriteString(fmt.Sprintf(summaryTruncatedNote, omittedRows, omittedFailures))
+	}
+
+	return sb.String()
+}
+
+// failureSections renders the details of each failed test for the job summary
+func failureSections(reports []TestReport) []string {
+	var sections []string
+	for _, report := range reports {
+		if report.Status != "fail" {
+			continue
+		}
+
+		var sb strings.Builder
+		result := report.Result
+		location := report.File
+		if report.Test != nil && report.Test.Line > 0 {
+			location = fmt.Sprintf("%s:%d", report.File, report.Test.Line)
+		}
+		sb.WriteString(fmt.Sprintf("#### %s\n\n`%s` · confidence %.2f", result.Name, location, result.Confidence))
+		if result.Code != "" {
+			sb.WriteString(fmt.Sprintf(" · `%s`", result.Code))
+		}
+		if ref := stepRef(result); ref != "" {
+			sb.WriteString(fmt.Sprintf(" at `%s`", ref))
+		}
+		sb.WriteString("\n\n")
+
+		details := result.Error + "\n"
+		if result.Actual != nil || result.Expected != nil {
+			details += fmt.Sprintf("actual:   %s\nexpected: %s\n", formatValue(result.Actual), formatValue(result.Expected))
+		}
+		fence := codeFence(details)
+		sb.WriteString(fence + "\n" + details + fence + "\n\n")
+		sections = append(sections, sb.String())
+	}
+	return sections
+}
+
+// codeFence returns a fence longer than any run of backticks in content, so the content
+// can't end the code block early
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, c := range content {
+		if c != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// githubStatus renders a result status for the job summary table
+func githubStatus(status string) string {
+	switch status {
+	case "pass":
+		return "✅ pass"
+	case "flaky":
+		return "⚠️ flaky"
+	case "not_run":
+		return "⏭️ not run"
+	default:
+		return "❌ fail"
+	}
+}
+
+// markdownCell escapes text for use inside a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// escapeWorkflowData escapes the message part of a workflow command
+func escapeWorkflowData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeWorkflowProperty escapes a property value (file, title) of a workflow command
+func escapeWorkflowProperty(s string) string {
+	s = escapeWorkflowData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
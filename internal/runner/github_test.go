@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestGitHubReporterAnnotatesFailures(t *testing.T) {
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+	t.Setenv("GITHUB_WORKSPACE", "")
+
+	var out bytes.Buffer
+	reporter := NewReporter(OutputGitHub, &out)
+
+	test := &parser.Test{Name: "adds, twice", Line: 7}
+	reporter.FileStart("math.vyb")
+	reporter.TestEnd(TestReport{
+		File:   "math.vyb",
+		Status: "fail",
+		Result: parser.TestResult{Name: test.Name, Error: "Expectation failed: expect: x == 5", Actual: 4, Expected: 5, Confidence: 0.9},
+		Test:   test,
+	})
+	reporter.FileEnd("math.vyb", FileSummary{})
+	if err := reporter.Finish(TestSummary{Total: 1, Failed: 1, AverageConfidence: 0.9}); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	want := "::error file=math.vyb,line=7,title=adds%2C twice::Expectation failed: expect: x == 5%0Aactual: 4%0Aexpected: 5\n"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("missing annotation %q in:\n%s", want, out.String())
+	}
+
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("job summary not written: %v", err)
+	}
+	for _, part := range []string{"| math.vyb | adds, twice | ❌ fail | 0.90 |", "`math.vyb:7`", "actual:   4\nexpected: 5"} {
+		if !strings.Contains(string(data), part) {
+			t.Errorf("job summary missing %q:\n%s", part, data)
+		}
+	}
+}
+
+func TestGitHubJobSummaryFencesFailureDetails(t *testing.T) {
+	report := TestReport{File: "md.vyb", Status: "fail", Result: parser.TestResult{Name: "renders", Error: "got ```go\nx\n``` and ````"}}
+	got := githubJobSummary(TestSummary{Total: 1, Failed: 1}, []TestReport{report})
+
+	want := "`````\ngot ```go\nx\n``` and ````\n`````\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("job summary missing %q:\n%s", want, got)
+	}
+}
+
+func TestGitHubJobSummaryFitsSizeLimit(t *testing.T) {
+	var reports []TestReport
+	for i := 0; i < 500; i++ {
+		reports = append(reports, TestReport{File: "big.vyb", Status: "pass", Result: parser.TestResult{Name: fmt.Sprintf("passes %d", i)}})
+	}
+	for i := 0; i < 400; i++ {
+		reports = append(reports, TestReport{File: "big.vyb", Status: "fail", Result: parser.TestResult{Name: fmt.Sprintf("fails %d", i), Error: strings.Repeat("x", 4000)}})
+	}
+
+	got := githubJobSummary(TestSummary{Total: 900, Passed: 500, Failed: 400}, reports)
+	if len(got) > maxJobSummaryBytes {
+		t.Errorf("job summary is %d bytes, over the %d byte limit", len(got), maxJobSummaryBytes)
+	}
+	// Failures are kept before the table rows
+	if !strings.Contains(got, "#### fails 0\n") || strings.Contains(got, "| File | Test |") {
+		t.Errorf("expected test rows to make room for failures")
+	}
+	if !strings.Contains(got, "_Truncated to fit GitHub's size limit: 900 test row(s) and ") {
+		t.Errorf("missing truncation note:\n%s", got[len(got)-300:])
+	}
+}
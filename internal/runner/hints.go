@@ -1,12 +1,13 @@
 package runner
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/vybtest/vyb/internal/parser"
 )
 
-// generateHints creates pattern-based suggestions for fixing failed tests
+// generateHints creates suggestions for fixing a failed test from its error code
 func generateHints(test *parser.Test, result parser.TestResult) []string {
 	var hints []string
 
@@ -14,78 +15,75 @@ func generateHints(test *parser.Test, result parser.TestResult) []string {
 		return hints // No hints needed for passing tests
 	}
 
-	errorMsg := strings.ToLower(result.Error)
-
-	// Pattern 1: Undefined variable
-	if strings.Contains(errorMsg, "undefined variable") {
+	switch ErrorCode(result.Code) {
+	case CodeUndefinedVar:
+		if len(result.Suggestions) > 0 {
+			hints = append(hints, capitalize(didYouMean(result.Suggestions))+" (similar variables in scope)")
+		}
 		hints = append(hints, "Check that all variables are defined in the 'given' block or assigned in 'when' statements")
 		hints = append(hints, "Verify variable names match exactly (case-sensitive)")
-	}
 
-	// Pattern 2: Unknown function
-	if strings.Contains(errorMsg, "unknown function") {
+	case CodeUnknownFunc:
+		if len(result.Suggestions) > 0 {
+			hints = append(hints, capitalize(didYouMean(result.Suggestions))+" (similar built-in or exported functions)")
+		}
 		hints = append(hints, "Ensure the function is defined in your modules (check vyb.config.yaml)")
 		hints = append(hints, "For external functions, verify the module path is correct")
 		hints = append(hints, "Check that the function is exported (for TypeScript: export function ...)")
-	}
 
-	// Pattern 3: External function failed
-	if strings.Contains(errorMsg, "external function") && strings.Contains(errorMsg, "failed") {
+	case CodeExternalError:
 		hints = append(hints, "The external function execution failed - check the implementation code")
 		hints = append(hints, "Review the error output from the language runtime (Node.js/Python)")
 		hints = append(hints, "Verify function arguments match the expected signature")
-	}
 
-	// Pattern 4: Module not found
-	if strings.Contains(errorMsg, "cannot find module") || strings.Contains(errorMsg, "modulenotfounderror") {
+	case CodeModuleNotFound:
 		hints = append(hints, "Module path in vyb.config.yaml may be incorrect")
 		hints = append(hints, "For TypeScript: ensure you've run 'npm run build' to compile to JavaScript")
 		hints = append(hints, "Check that the module file exists at the specified path")
-	}
 
-	// Pattern 5: Comparison operator issues
-	if strings.Contains(errorMsg, "expectation failed") {
-		// Extract the failed expectation
-		if strings.Contains(errorMsg, "==") {
+	case CodeBridgeCrash:
+		hints = append(hints, "The language runtime exited without answering - check the module for syntax errors")
+		hints = append(hints, "Make sure the runtime (node, python3 or lua) is installed and on PATH")
+
+	case CodeAssertMismatch:
+		// What the values say about the bug comes before generic advice
+		hints = append(hints, valueHints(result)...)
+		switch result.Operator {
+		case "==":
 			hints = append(hints, "The equality check failed - actual value doesn't match expected")
 			hints = append(hints, "Consider logging the actual value to debug: add a test step that assigns it to a variable")
-		}
-		if strings.Contains(errorMsg, "!=") {
+		case "!=":
 			hints = append(hints, "The inequality check failed - values are actually equal")
-		}
-		if strings.Contains(errorMsg, ">") || strings.Contains(errorMsg, "<") {
+		case ">", "<", ">=", "<=":
 			hints = append(hints, "The numeric comparison failed - check the actual value range")
 			hints = append(hints, "Ensure both sides of the comparison are numbers")
-		}
-		if strings.Contains(errorMsg, "contains") {
+		case "contains":
 			hints = append(hints, "The string doesn't contain the expected substring")
 			hints = append(hints, "Check for case sensitivity - string comparisons are case-sensitive")
+		case "startsWith", "endsWith":
+			hints = append(hints, "The string doesn't "+map[string]string{"startsWith": "start", "endsWith": "end"}[result.Operator]+" with the expected text")
+			hints = append(hints, "Check for case sensitivity - string comparisons are case-sensitive")
 		}
-	}
 
-	// Pattern 6: Type mismatches
-	if strings.Contains(errorMsg, "must be a number") || strings.Contains(errorMsg, "must be numbers") {
+	case CodeTypeMismatch:
 		hints = append(hints, "Type mismatch detected - expected number but got a different type")
 		hints = append(hints, "Ensure the function returns a number, not a string or other type")
-	}
 
-	// Pattern 7: Division by zero
-	if strings.Contains(errorMsg, "division by zero") {
+	case CodeDivisionByZero:
 		hints = append(hints, "Division by zero detected - add a check for zero before dividing")
 		hints = append(hints, "Consider testing edge cases separately")
-	}
 
-	// Pattern 8: Python JSON serialization
-	if strings.Contains(errorMsg, "not json serializable") {
-		hints = append(hints, "Python function is returning a class instance, not a JSON-serializable value")
+	case CodeUnserializable:
+		hints = append(hints, "The function is returning a class instance or other value that can't be sent as JSON")
 		hints = append(hints, "Return a dict, list, number, string, or boolean instead")
 		hints = append(hints, "For complex objects, call .to_dict() or similar serialization method")
-	}
 
-	// Pattern 9: JSON-serializable types issue
-	if strings.Contains(errorMsg, "argument after * must be an iterable") {
-		hints = append(hints, "Python bridge received null/None instead of a list for function arguments")
-		hints = append(hints, "This is likely a Vyb internal issue - the function call may be malformed")
+	case CodeInvalidStatement:
+		hints = append(hints, "Write 'when' steps as assignments: 'result = functionName(arg1, arg2)'")
+
+	case CodeInvalidExpectation:
+		hints = append(hints, "Write 'then' steps as 'expect: <value> <operator> <value>'")
+		hints = append(hints, "Supported operators: ==, !=, >, <, >=, <=, contains, startsWith, endsWith")
 	}
 
 	// Default hint for any failure
@@ -97,34 +95,59 @@ func generateHints(test *parser.Test, result parser.TestResult) []string {
 	return hints
 }
 
-// getConfidenceNote provides human-readable interpretation of confidence level
-func getConfidenceNote(confidence float64) string {
+// getConfidenceNote provides human-readable interpretation of confidence level. When
+// history has resolved failures of similar tests, it says how often they were real bugs.
+func getConfidenceNote(confidence float64, history *calibrationStats) string {
+	var note string
 	if confidence >= 0.95 {
-		return "Very high confidence - test failure likely indicates a real bug in the implementation"
+		note = "Very high confidence - test failure likely indicates a real bug in the implementation"
 	} else if confidence >= 0.85 {
-		return "High confidence - test is probably correct, check implementation first"
+		note = "High confidence - test is probably correct, check implementation first"
 	} else if confidence >= 0.70 {
-		return "Moderate confidence - test may need review, verify requirements"
+		note = "Moderate confidence - test may need review, verify requirements"
 	} else if confidence >= 0.50 {
-		return "Low confidence - test is uncertain, check requirements before fixing implementation"
+		note = "Low confidence - test is uncertain, check requirements before fixing implementation"
 	} else {
-		return "Very low confidence - test is likely incorrect or based on unclear requirements"
+		note = "Very low confidence - test is likely incorrect or based on unclear requirements"
 	}
-}
 
-// getFailedStep determines which step of the test failed
-func getFailedStep(errorMsg string) string {
-	errorLower := strings.ToLower(errorMsg)
+	if history == nil {
+		return note
+	}
 
-	if strings.Contains(errorLower, "failed to execute statement") {
-		return "when" // Failed during execution
+	note += fmt.Sprintf(". History: %d of %d past failures at this confidence %s were code bugs (%.0f%%)",
+		history.CodeBugs, history.Resolved, history.Scope, history.codeBugRate()*100)
+	switch rate := history.codeBugRate(); {
+	case rate < confidence-calibrationTolerance:
+		note += " - these tests have been overconfident, check the test as well"
+	case rate > confidence+calibrationTolerance:
+		note += " - these tests have been more reliable than stated, check the implementation first"
 	}
-	if strings.Contains(errorLower, "failed to check expectation") || strings.Contains(errorLower, "expectation failed") {
-		return "then" // Failed during assertion
+	return note
+}
+
+// capitalize upper-cases the first letter of a hint
+func capitalize(s string) string {
+	if s == "" {
+		return s
 	}
-	if strings.Contains(errorLower, "undefined variable") {
-		return "given" // Missing variable setup
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// failedStepIndex returns the index of the failed step, or nil when no step failed
+// (a pointer so that index 0 still shows up in the output)
+func failedStepIndex(result parser.TestResult) *int {
+	if result.Passed || result.Step == "" {
+		return nil
 	}
+	index := result.StepIndex
+	return &index
+}
 
-	return "unknown"
+// stepRef names the failed step for people, e.g. "then[1]"
+func stepRef(result parser.TestResult) string {
+	if result.Passed || result.Step == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s[%d]", result.Step, result.StepIndex)
 }
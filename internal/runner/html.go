@@ -0,0 +1,272 @@
+package runner
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// htmlReporter collects results and writes a single self-contained HTML page
+// (no external assets) that can be opened straight from a CI artifact
+type htmlReporter struct {
+	baseReporter
+	out   io.Writer
+	files []*htmlFile
+}
+
+type htmlReport struct {
+	Version   string
+	Generated string
+	Summary   TestSummary
+	Files     []*htmlFile
+	Buckets   []htmlBucket
+}
+
+type htmlFile struct {
+	Name     string
+	Tests    []htmlTest
+	Summary  FileSummary
+	Duration string
+}
+
+type htmlTest struct {
+	Name           string
+	Status         string
+	StatusLabel    string
+	Line           int
+	Confidence     float64
+	Duration       string
+	Attempts       int
+	Error          string
+	ErrorCode      string
+	FailedStep     string
+	Actual         string
+	Expected       string
+	HasValues      bool
+	Diff           []string
+	Hints          []string
+	ConfidenceNote string
+	Code           string
+}
+
+// htmlBucket is one bar of the confidence distribution chart
+type htmlBucket struct {
+	Label   string
+	Count   int
+	Percent int // Bar height relative to the largest bucket
+}
+
+func (r *htmlReporter) FileStart(file string) {
+	r.files = append(r.files, &htmlFile{Name: file})
+}
+
+func (r *htmlReporter) TestEnd(report TestReport) {
+	file := r.files[len(r.files)-1]
+
+	result := report.Result
+	test := htmlTest{
+		Name:        result.Name,
+		Status:      report.Status,
+		StatusLabel: strings.ReplaceAll(report.Status, "_", " "),
+		Confidence:  result.Confidence,
+		Duration:    htmlDuration(float64(result.Duration) / 1e9),
+		Attempts:    report.Attempts,
+		Error:       result.Error,
+		ErrorCode:   result.Code,
+		FailedStep:  stepRef(result),
+	}
+	if report.Test != nil {
+		test.Line = report.Test.Line
+		test.Code = formatTestCode(report.Test)
+		if report.Status != "not_run" {
+			suggestResult := newSuggestResult(report)
+			test.Hints = suggestResult.Hints
+			test.ConfidenceNote = suggestResult.ConfidenceNote
+		}
+	}
+	if report.Status == "fail" && (result.Actual != nil || result.Expected != nil) {
+		test.HasValues = true
+		test.Actual = formatValue(result.Actual)
+		test.Expected = formatValue(result.Expected)
+		test.Diff = newResultDiff(result).Compact()
+	}
+	file.Tests = append(file.Tests, test)
+}
+
+func (r *htmlReporter) FileEnd(file string, summary FileSummary) {
+	if len(r.files) > 0 {
+		current := r.files[len(r.files)-1]
+		current.Summary = summary
+		current.Duration = htmlDuration(summary.Duration)
+	}
+}
+
+func (r *htmlReporter) Finish(summary TestSummary) error {
+	return htmlTemplate.Execute(r.out, htmlReport{
+		Version:   Version,
+		Generated: time.Now().Format("2006-01-02 15:04:05 MST"),
+		Summary:   summary,
+		Files:     r.files,
+		Buckets:   r.confidenceBuckets(),
+	})
+}
+
+// confidenceBuckets groups executed tests by confidence in steps of 0.1
+func (r *htmlReporter) confidenceBuckets() []htmlBucket {
+	buckets := make([]htmlBucket, 10)
+	for i := range buckets {
+		buckets[i].Label = fmt.Sprintf("%.1f", float64(i)/10)
+	}
+
+	for _, file := range r.files {
+		for _, test := range file.Tests {
+			if test.Status == "not_run" {
+				continue
+			}
+			i := int(test.Confidence * 10)
+			if i > 9 {
+				i = 9
+			}
+			if i < 0 {
+				i = 0
+			}
+			buckets[i].Count++
+		}
+	}
+
+	max := 0
+	for _, bucket := range buckets {
+		if bucket.Count > max {
+			max = bucket.Count
+		}
+	}
+	if max > 0 {
+		for i := range buckets {
+			buckets[i].Percent = buckets[i].Count * 100 / max
+		}
+	}
+	return buckets
+}
+
+// htmlDuration formats seconds for display
+func htmlDuration(seconds float64) string {
+	if seconds < 1 {
+		return fmt.Sprintf("%.1fms", seconds*1000)
+	}
+	return fmt.Sprintf("%.2fs", seconds)
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Vyb test report</title>
+<style>
+  body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; background: #f6f8fa; color: #1f2328; }
+  main { max-width: 1100px; margin: 0 auto; padding: 24px; }
+  h1 { font-size: 24px; margin: 0 0 4px; }
+  .meta { color: #656d76; font-size: 13px; margin-bottom: 24px; }
+  .cards { display: flex; gap: 12px; flex-wrap: wrap; margin-bottom: 24px; }
+  .card { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 12px 16px; min-width: 110px; }
+  .card .value { font-size: 22px; font-weight: 600; }
+  .card .label { color: #656d76; font-size: 12px; text-transform: uppercase; }
+  .pass { color: #1a7f37; } .fail { color: #cf222e; } .flaky { color: #9a6700; } .not_run { color: #656d76; }
+  .panel { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 16px; margin-bottom: 24px; }
+  .panel h2 { font-size: 16px; margin: 0 0 12px; }
+  .chart { display: flex; align-items: flex-end; gap: 6px; height: 120px; }
+  .bar { flex: 1; display: flex; flex-direction: column; justify-content: flex-end; align-items: center; height: 100%; font-size: 11px; color: #656d76; }
+  .bar .fill { width: 100%; background: #54aeff; border-radius: 3px 3px 0 0; min-height: 1px; }
+  details.file { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; margin-bottom: 12px; }
+  details.file > summary { padding: 12px 16px; cursor: pointer; font-weight: 600; display: flex; gap: 12px; }
+  details.file > summary .counts { font-weight: normal; color: #656d76; margin-left: auto; }
+  .test { border-top: 1px solid #d0d7de; padding: 10px 16px; }
+  .test .head { display: flex; gap: 12px; align-items: baseline; }
+  .test .name { font-weight: 600; }
+  .test .info { margin-left: auto; color: #656d76; font-size: 12px; white-space: nowrap; }
+  .badge { font-size: 11px; font-weight: 600; text-transform: uppercase; }
+  .error { color: #cf222e; margin: 8px 0; white-space: pre-wrap; }
+  .error-code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 12px; background: #ffebe9; border-radius: 4px; padding: 1px 4px; }
+  .values { display: grid; grid-template-columns: 1fr 1fr; gap: 8px; margin: 8px 0; }
+  .values div > span, .values-diff > span { font-size: 12px; color: #656d76; }
+  pre { background: #f6f8fa; border: 1px solid #d0d7de; border-radius: 6px; padding: 8px; overflow-x: auto; margin: 4px 0; font-size: 12px; }
+  ul.hints { margin: 8px 0; padding-left: 20px; }
+  .note { color: #656d76; font-size: 13px; font-style: italic; }
+  details.code > summary { cursor: pointer; color: #0969da; font-size: 13px; }
+</style>
+</head>
+<body>
+<main>
+  <h1>🌊 Vyb test report</h1>
+  <div class="meta">Vyb v{{.Version}} · generated {{.Generated}}{{if .Summary.Seed}} · seed {{.Summary.Seed}}{{end}}{{if .Summary.Shard}} · shard {{.Summary.Shard}}{{end}}</div>
+
+  <div class="cards">
+    <div class="card"><div class="value">{{.Summary.Total}}</div><div class="label">Total</div></div>
+    <div class="card"><div class="value pass">{{.Summary.Passed}}</div><div class="label">Passed</div></div>
+    <div class="card"><div class="value fail">{{.Summary.Failed}}</div><div class="label">Failed</div></div>
+    {{- if .Summary.Flaky}}
+    <div class="card"><div class="value flaky">{{.Summary.Flaky}}</div><div class="label">Flaky</div></div>
+    {{- end}}
+    {{- if .Summary.NotRun}}
+    <div class="card"><div class="value not_run">{{.Summary.NotRun}}</div><div class="label">Not run</div></div>
+    {{- end}}
+    <div class="card"><div class="value">{{printf "%.2f" .Summary.AverageConfidence}}</div><div class="label">Avg confidence</div></div>
+    <div class="card"><div class="value">{{printf "%.3fs" .Summary.Duration}}</div><div class="label">Time</div></div>
+  </div>
+
+  <div class="panel">
+    <h2>Confidence distribution</h2>
+    <div class="chart">
+      {{- range .Buckets}}
+      <div class="bar" title="{{.Count}} test(s) with confidence {{.Label}}+"><span>{{if .Count}}{{.Count}}{{end}}</span><div class="fill" style="height: {{.Percent}}%"></div><span>{{.Label}}</span></div>
+      {{- end}}
+    </div>
+    <div class="meta">Min {{printf "%.2f" .Summary.MinConfidence}} · max {{printf "%.2f" .Summary.MaxConfidence}}</div>
+  </div>
+
+  {{- range .Files}}
+  <details class="file"{{if .Summary.Failed}} open{{end}}>
+    <summary>
+      <span class="{{if .Summary.Failed}}fail{{else}}pass{{end}}">{{if .Summary.Failed}}✗{{else}}✓{{end}}</span>
+      {{.Name}}
+      <span class="counts">{{.Summary.Passed}} passed · {{.Summary.Failed}} failed{{if .Summary.Flaky}} · {{.Summary.Flaky}} flaky{{end}}{{if .Summary.NotRun}} · {{.Summary.NotRun}} not run{{end}} · {{.Duration}}</span>
+    </summary>
+    {{- range .Tests}}
+    <div class="test">
+      <div class="head">
+        <span class="badge {{.Status}}">{{.StatusLabel}}</span>
+        <span class="name">{{.Name}}</span>
+        <span class="info">{{if .Line}}line {{.Line}} · {{end}}confidence {{printf "%.2f" .Confidence}} · {{.Duration}}{{if .Attempts}} · {{.Attempts}} attempts{{end}}</span>
+      </div>
+      {{- if .Error}}
+      <div class="error">{{if .ErrorCode}}<span class="error-code">{{.ErrorCode}}</span> {{end}}{{if .FailedStep}}[{{.FailedStep}}] {{end}}{{.Error}}</div>
+      {{- end}}
+      {{- if .HasValues}}
+      <div class="values">
+        <div><span>Actual</span><pre>{{.Actual}}</pre></div>
+        <div><span>Expected</span><pre>{{.Expected}}</pre></div>
+      </div>
+      {{- if .Diff}}
+      <div class="values-diff"><span>Diff (- expected, + actual)</span><pre>{{range .Diff}}{{.}}
+{{end}}</pre></div>
+      {{- end}}
+      {{- end}}
+      {{- if and .Hints (ne .Status "pass")}}
+      <ul class="hints">{{range .Hints}}<li>{{.}}</li>{{end}}</ul>
+      {{- end}}
+      {{- if and .ConfidenceNote (eq .Status "fail")}}
+      <div class="note">{{.ConfidenceNote}}</div>
+      {{- end}}
+      {{- if .Code}}
+      <details class="code"><summary>Test code</summary><pre>{{.Code}}</pre></details>
+      {{- end}}
+    </div>
+    {{- end}}
+  </details>
+  {{- end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestHTMLReporterRendersSelfContainedPage(t *testing.T) {
+	var out bytes.Buffer
+	reporter := NewReporter(OutputHTML, &out)
+
+	test := &parser.Test{Name: "renders <b>", Confidence: 0.75, When: []string{"x = add(1, 1)"}, Then: []string{"expect: x == 3"}}
+	reporter.FileStart("math.vyb")
+	reporter.TestEnd(TestReport{
+		File:   "math.vyb",
+		Status: "fail",
+		Result: parser.TestResult{Name: test.Name, Error: "Expectation failed: expect: x == 3", Actual: 2, Expected: 3, Confidence: 0.75},
+		Test:   test,
+	})
+	reporter.FileEnd("math.vyb", FileSummary{Total: 1, Failed: 1})
+	if err := reporter.Finish(TestSummary{Total: 1, Failed: 1, AverageConfidence: 0.75}); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	page := out.String()
+	for _, part := range []string{
+		`<details class="file" open>`,
+		"renders &lt;b&gt;",
+		"<pre>2</pre>",
+		"<pre>3</pre>",
+		`title="1 test(s) with confidence 0.7+"`,
+	} {
+		if !strings.Contains(page, part) {
+			t.Errorf("HTML report missing %q", part)
+		}
+	}
+	if strings.Contains(page, "<script src") || strings.Contains(page, `<link rel="stylesheet"`) {
+		t.Error("HTML report should not reference external assets")
+	}
+}
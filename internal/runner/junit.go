@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JUnit XML elements (the de-facto schema understood by CI dashboards)
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string          `xml:"name,attr"`
+	Classname  string          `xml:"classname,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Failure    *junitProblem   `xml:"failure,omitempty"`
+	Error      *junitProblem   `xml:"error,omitempty"`
+	Skipped    *junitSkipped   `xml:"skipped,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// junitReporter collects results and writes JUnit XML when the run finishes
+type junitReporter struct {
+	baseReporter
+	out     io.Writer
+	reports []TestReport
+}
+
+func (r *junitReporter) TestEnd(report TestReport) {
+	r.reports = append(r.reports, report)
+}
+
+func (r *junitReporter) Finish(summary TestSummary) error {
+	return writeJUnit(r.out, summary, r.reports)
+}
+
+// writeJUnit renders the run as JUnit XML: one <testsuite> per file, one <testcase> per test
+func writeJUnit(w io.Writer, summary TestSummary, reports []TestReport) error {
+	root := junitTestSuites{
+		Name:    "vyb",
+		Tests:   summary.Total,
+		Skipped: summary.NotRun,
+		Time:    junitTime(summary.Duration),
+	}
+
+	suiteIndex := make(map[string]int)
+	var suiteTimes []float64
+	var confidenceSums []float64
+	var executed []int
+	for _, report := range reports {
+		i, ok := suiteIndex[report.File]
+		if !ok {
+			i = len(root.Suites)
+			suiteIndex[report.File] = i
+			root.Suites = append(root.Suites, junitTestSuite{Name: report.File})
+			suiteTimes = append(suiteTimes, 0)
+			confidenceSums = append(confidenceSums, 0)
+			executed = append(executed, 0)
+		}
+		suite := &root.Suites[i]
+
+		result := report.Result
+		testCase := junitTestCase{
+			Name:      result.Name,
+			Classname: report.File,
+			Time:      junitTime(float64(result.Duration) / 1e9),
+			Properties: []junitProperty{
+				{Name: "confidence", Value: fmt.Sprintf("%.2f", result.Confidence)},
+			},
+		}
+		if result.Attempts > 1 {
+			testCase.Properties = append(testCase.Properties, junitProperty{Name: "attempts", Value: fmt.Sprintf("%d", result.Attempts)})
+		}
+		if result.Flaky {
+			testCase.Properties = append(testCase.Properties, junitProperty{Name: "flaky", Value: "true"})
+		}
+
+		if report.Status != "not_run" {
+			confidenceSums[i] += result.Confidence
+			executed[i]++
+		}
+
+		switch report.Status {
+		case "not_run":
+			testCase.Skipped = &junitSkipped{Message: notRunMessage(report)}
+			suite.Skipped++
+		case "fail":
+			problem := &junitProblem{
+				Message: result.Error,
+				Type:    result.Code,
+				Body:    junitFailureBody(result.Error, result.Actual, result.Expected),
+			}
+			if ref := stepRef(result); ref != "" {
+				testCase.Properties = append(testCase.Properties, junitProperty{Name: "failed_step", Value: ref})
+			}
+			if result.Code == string(CodeAssertMismatch) {
+				testCase.Failure = problem
+				suite.Failures++
+				root.Failures++
+			} else {
+				testCase.Error = problem
+				suite.Errors++
+				root.Errors++
+			}
+		}
+
+		suite.Tests++
+		suiteTimes[i] += float64(result.Duration) / 1e9
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	// Suite-level duration, confidence overview, and how to reproduce the run
+	for i := range root.Suites {
+		suite := &root.Suites[i]
+		suite.Time = junitTime(suiteTimes[i])
+		if executed[i] > 0 {
+			suite.Properties = append(suite.Properties, junitProperty{Name: "average_confidence", Value: fmt.Sprintf("%.2f", confidenceSums[i]/float64(executed[i]))})
+		}
+		if summary.Seed != 0 {
+			suite.Properties = append(suite.Properties, junitProperty{Name: "seed", Value: strconv.FormatInt(summary.Seed, 10)})
+		}
+		if summary.Shard != "" {
+			suite.Properties = append(suite.Properties, junitProperty{Name: "shard", Value: summary.Shard})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitTime formats seconds the way JUnit consumers expect (fixed-point)
+func junitTime(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
+
+// junitFailureBody builds the failure text including actual/expected values
+func junitFailureBody(errorMsg string, actual, expected interface{}) string {
+	var sb strings.Builder
+	sb.WriteString(errorMsg)
+	if actual != nil || expected != nil {
+		sb.WriteString(fmt.Sprintf("\nactual: %s\nexpected: %s", formatValue(actual), formatValue(expected)))
+	}
+	return sb.String()
+}
+
+// formatValue renders a test value compactly: strings as-is, everything else as JSON
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	reports := []TestReport{
+		{File: "math.vyb", Status: "pass", Result: parser.TestResult{Name: "adds", Passed: true, Duration: 1500000, Confidence: 0.9}},
+		{File: "math.vyb", Status: "fail", Result: parser.TestResult{
+			Name: `compares <a> & "b"`, Error: "Expectation failed: expect: x < 3", Code: string(CodeAssertMismatch),
+			Step: "then", StepIndex: 0, Duration: 2000000, Confidence: 0.8, Actual: 4.0, Expected: 3.0,
+		}},
+		{File: "math.vyb", Status: "flaky", Attempts: 2, Result: parser.TestResult{Name: "retried", Passed: true, Flaky: true, Attempts: 2, Confidence: 0.7}},
+		{File: "io.vyb", Status: "fail", Result: parser.TestResult{
+			Name: "throws", Error: "Failed to execute statement 'x = read()': external function error: ]]> boom",
+			Code: string(CodeExternalError), Step: "when", StepIndex: 1, Confidence: 1,
+		}},
+		{File: "io.vyb", Status: "not_run", Reason: "failure limit reached", Result: parser.TestResult{Name: "skipped", Confidence: 0.5}},
+	}
+	summary := TestSummary{Total: 5, Passed: 2, Failed: 2, Flaky: 1, NotRun: 1, Duration: 0.0035, Seed: 42, Shard: "1/2"}
+
+	var out bytes.Buffer
+	if err := writeJUnit(&out, summary, reports); err != nil {
+		t.Fatalf("writeJUnit failed: %v", err)
+	}
+	if out.String() != junitGolden {
+		t.Errorf("JUnit output:\n%s\nwant:\n%s", out.String(), junitGolden)
+	}
+}
+
+// junitGolden is the expected output of TestWriteJUnit: one suite per file, failed
+// expectations as <failure>, other errors as <error>, not-run tests as <skipped>, and
+// the seed and shard on every suite
+const junitGolden = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="vyb" tests="5" failures="1" errors="1" skipped="1" time="0.004">
+  <testsuite name="math.vyb" tests="3" failures="1" errors="0" skipped="0" time="0.004">
+    <properties>
+      <property name="average_confidence" value="0.80"></property>
+      <property name="seed" value="42"></property>
+      <property name="shard" value="1/2"></property>
+    </properties>
+    <testcase name="adds" classname="math.vyb" time="0.002">
+      <properties>
+        <property name="confidence" value="0.90"></property>
+      </properties>
+    </testcase>
+    <testcase name="compares &lt;a&gt; &amp; &#34;b&#34;" classname="math.vyb" time="0.002">
+      <properties>
+        <property name="confidence" value="0.80"></property>
+        <property name="failed_step" value="then[0]"></property>
+      </properties>
+      <failure message="Expectation failed: expect: x &lt; 3" type="VYB_ASSERT_MISMATCH"><![CDATA[Expectation failed: expect: x < 3
+actual: 4
+expected: 3]]></failure>
+    </testcase>
+    <testcase name="retried" classname="math.vyb" time="0.000">
+      <properties>
+        <property name="confidence" value="0.70"></property>
+        <property name="attempts" value="2"></property>
+        <property name="flaky" value="true"></property>
+      </properties>
+    </testcase>
+  </testsuite>
+  <testsuite name="io.vyb" tests="2" failures="0" errors="1" skipped="1" time="0.000">
+    <properties>
+      <property name="average_confidence" value="1.00"></property>
+      <property name="seed" value="42"></property>
+      <property name="shard" value="1/2"></property>
+    </properties>
+    <testcase name="throws" classname="io.vyb" time="0.000">
+      <properties>
+        <property name="confidence" value="1.00"></property>
+        <property name="failed_step" value="when[1]"></property>
+      </properties>
+      <error message="Failed to execute statement &#39;x = read()&#39;: external function error: ]]&gt; boom" type="VYB_EXTERNAL_ERROR"><![CDATA[Failed to execute statement 'x = read()': external function error: ]]]]><![CDATA[> boom]]></error>
+    </testcase>
+    <testcase name="skipped" classname="io.vyb" time="0.000">
+      <properties>
+        <property name="confidence" value="0.50"></property>
+      </properties>
+      <skipped message="not run (failure limit reached)"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
@@ -0,0 +1,539 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vybtest/vyb/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Codes for problems only vyb lint reports. Problems that would fail a test at run
+// time keep their run-time code (VYB_UNDEFINED_VAR, VYB_UNKNOWN_FUNC, ...).
+const (
+	CodeParseError    ErrorCode = "VYB_PARSE_ERROR"    // The file can't be parsed as a test file
+	CodeUnknownKey    ErrorCode = "VYB_UNKNOWN_KEY"    // A key that is not part of a test
+	CodeDuplicateTest ErrorCode = "VYB_DUPLICATE_TEST" // Two tests in a file have the same name
+	CodeInvalidTest   ErrorCode = "VYB_INVALID_TEST"   // A test field has the wrong type or value
+)
+
+// Diagnostic severities: errors make vyb lint fail, warnings don't
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// testKeys are the keys a test may have
+var testKeys = []string{"confidence", "tags", "given", "when", "then", "retries", "llm_verify"}
+
+// identifierPattern matches variable and function names
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// yamlLinePattern finds the line number in a YAML error message
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// Diagnostic is a problem vyb lint found in a test file
+type Diagnostic struct {
+	File       string    `json:"file"`
+	Line       int       `json:"line,omitempty"`
+	Code       ErrorCode `json:"code"`
+	Severity   string    `json:"severity"`
+	Message    string    `json:"message"`
+	Test       string    `json:"test,omitempty"`
+	DidYouMean []string  `json:"did_you_mean,omitempty"`
+}
+
+// LintReport is the JSON output of vyb lint
+type LintReport struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Summary     LintSummary  `json:"summary"`
+}
+
+// LintSummary counts the files checked and the problems found
+type LintSummary struct {
+	Files    int `json:"files"`
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+}
+
+// Lint checks the test files matching pattern without running them and writes the
+// diagnostics as text, or as JSON when format is OutputJSON. Returns an error when
+// any diagnostic is an error.
+func Lint(pattern string, format OutputFormat, out io.Writer) error {
+	files, err := findTestFiles(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to find test files: %w", err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	report := LintReport{Diagnostics: []Diagnostic{}, Summary: LintSummary{Files: len(files)}}
+	config, err := parser.LoadConfig(cwd)
+	if err != nil {
+		report.Diagnostics = append(report.Diagnostics, Diagnostic{File: "vyb.config.yaml", Code: CodeParseError, Severity: SeverityError, Message: err.Error()})
+	}
+	functions, moduleDiagnostics := exportedFunctions(cwd, config)
+	report.Diagnostics = append(report.Diagnostics, moduleDiagnostics...)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			report.Diagnostics = append(report.Diagnostics, Diagnostic{File: file, Code: CodeParseError, Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+		report.Diagnostics = append(report.Diagnostics, lintFile(file, data, functions, config != nil && len(config.Modules) > 0)...)
+	}
+
+	for _, d := range report.Diagnostics {
+		if d.Severity == SeverityError {
+			report.Summary.Errors++
+		} else {
+			report.Summary.Warnings++
+		}
+	}
+
+	if format == OutputJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		writeLintText(out, pattern, report)
+	}
+
+	if report.Summary.Errors > 0 {
+		return fmt.Errorf("lint found %d error(s)", report.Summary.Errors)
+	}
+	return nil
+}
+
+// writeLintText prints one line per diagnostic, compiler style, and a summary
+func writeLintText(out io.Writer, pattern string, report LintReport) {
+	if report.Summary.Files == 0 && len(report.Diagnostics) == 0 {
+		fmt.Fprintln(out, "No test files found matching pattern:", pattern)
+		return
+	}
+
+	for _, d := range report.Diagnostics {
+		location := d.File
+		if d.Line > 0 {
+			location += ":" + strconv.Itoa(d.Line)
+		}
+		fmt.Fprintf(out, "%s: %s %s: %s\n", location, d.Severity, d.Code, d.Message)
+	}
+
+	if len(report.Diagnostics) == 0 {
+		fmt.Fprintf(out, "✅ No problems found in %d file(s)\n", report.Summary.Files)
+		return
+	}
+	fmt.Fprintf(out, "\n%d error(s), %d warning(s) in %d file(s)\n", report.Summary.Errors, report.Summary.Warnings, report.Summary.Files)
+}
+
+// exportedFunctions statically scans the configured modules for the functions tests can
+// call, including the built-ins. Returns nil when a module can't be read, since calls
+// can't be checked then. The result is only complete without modules: a scan can miss
+// what a module exports.
+func exportedFunctions(dir string, config *parser.Config) (map[string]bool, []Diagnostic) {
+	functions := make(map[string]bool)
+	for _, name := range builtinFunctions {
+		functions[name] = true
+	}
+	if config == nil {
+		return functions, nil
+	}
+
+	var diagnostics []Diagnostic
+	for _, module := range config.Modules {
+		path := module
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, module)
+		}
+		names, err := moduleFunctions(path)
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{
+				File:     "vyb.config.yaml",
+				Code:     CodeModuleNotFound,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("cannot read module %s, so function calls are not checked: %v", module, err),
+			})
+			continue
+		}
+		for _, name := range names {
+			functions[name] = true
+		}
+	}
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+	return functions, nil
+}
+
+// linter collects the diagnostics of one test file
+type linter struct {
+	file        string
+	functions   map[string]bool // Functions tests can call (nil = unknown, calls aren't checked)
+	scanned     bool            // functions came from scanning modules and may be incomplete
+	diagnostics []Diagnostic
+}
+
+// lintFile checks a test file's structure, steps and names
+func lintFile(file string, data []byte, functions map[string]bool, scanned bool) []Diagnostic {
+	l := &linter{file: file, functions: functions, scanned: scanned}
+	l.lint(data)
+
+	// Whatever makes the parser reject the file must be reported
+	if _, err := parser.ParseBytes(file, data); err != nil && !l.hasErrors() {
+		l.report(1, "", CodeParseError, SeverityError, err.Error())
+	}
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		return l.diagnostics[i].Line < l.diagnostics[j].Line
+	})
+	return l.diagnostics
+}
+
+func (l *linter) report(line int, test string, code ErrorCode, severity, format string, args ...interface{}) *Diagnostic {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		File:     l.file,
+		Line:     line,
+		Code:     code,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Test:     test,
+	})
+	return &l.diagnostics[len(l.diagnostics)-1]
+}
+
+func (l *linter) hasErrors() bool {
+	for _, d := range l.diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) lint(data []byte) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		line := 0
+		if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+		}
+		l.report(line, "", CodeParseError, SeverityError, "invalid YAML: %s", strings.TrimPrefix(err.Error(), "yaml: "))
+		return
+	}
+	if len(doc.Content) == 0 {
+		l.report(0, "", CodeParseError, SeverityError, "the file has no tests")
+		return
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		l.report(root.Line, "", CodeParseError, SeverityError, "a test file must map test names to tests")
+		return
+	}
+
+	if mappingValue(root, "test") != nil {
+		l.lintLegacy(root)
+		return
+	}
+
+	defined := make(map[string]int) // Test name -> line
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		name := key.Value
+
+		if value.Kind != yaml.MappingNode {
+			l.report(key.Line, "", CodeUnknownKey, SeverityError, "top-level key %q is not a test (a test maps when and then steps), so the file can't be parsed", name)
+			continue
+		}
+		if mappingValue(value, "when") == nil && mappingValue(value, "then") == nil {
+			l.report(key.Line, "", CodeUnknownKey, SeverityWarning, "top-level key %q has no when or then steps and is ignored", name)
+			continue
+		}
+
+		if first, ok := defined[name]; ok {
+			l.report(key.Line, name, CodeDuplicateTest, SeverityError, "duplicate test name %q (first defined on line %d)", name, first)
+		} else {
+			defined[name] = key.Line
+		}
+		l.lintTest(name, key.Line, value, true)
+	}
+}
+
+// lintLegacy checks a file in the legacy format: a single test under "test:"
+func (l *linter) lintLegacy(root *yaml.Node) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "test" {
+			l.report(key.Line, "", CodeUnknownKey, SeverityWarning, "top-level key %q is ignored next to 'test:'", key.Value)
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			l.report(key.Line, "", CodeInvalidTest, SeverityError, "'test:' must be a mapping")
+			continue
+		}
+
+		name := ""
+		if node := mappingValue(value, "name"); node != nil {
+			name = node.Value
+		}
+		if name == "" {
+			l.report(key.Line, "", CodeInvalidTest, SeverityError, "the test has no name")
+		}
+		l.lintTest(name, key.Line, value, false)
+	}
+}
+
+// lintTest checks a test's keys and steps. Missing steps are errors in the name-as-key
+// format (the parser rejects the file) and warnings in the legacy format.
+func (l *linter) lintTest(name string, line int, test *yaml.Node, strict bool) {
+	allowed := testKeys
+	if !strict {
+		allowed = append([]string{"name"}, testKeys...)
+	}
+
+	scope := make(map[string]bool)
+	var when, then *yaml.Node
+	for i := 0; i+1 < len(test.Content); i += 2 {
+		key, value := test.Content[i], test.Content[i+1]
+		switch key.Value {
+		case "name":
+			if !strict {
+				continue
+			}
+		case "confidence":
+			if confidence, err := strconv.ParseFloat(value.Value, 64); value.Kind != yaml.ScalarNode || err != nil || confidence < 0 || confidence > 1 {
+				l.report(key.Line, name, CodeInvalidTest, SeverityError, "confidence must be a number between 0 and 1, got %q", value.Value)
+			}
+			continue
+		case "tags":
+			if !isScalarSequence(value) {
+				l.report(key.Line, name, CodeInvalidTest, SeverityError, "tags must be a list of names")
+			}
+			continue
+		case "retries":
+			if retries, err := strconv.Atoi(value.Value); value.Kind != yaml.ScalarNode || err != nil || retries < 0 {
+				l.report(key.Line, name, CodeInvalidTest, SeverityError, "retries must be a whole number of at least 0, got %q", value.Value)
+			}
+			continue
+		case "given":
+			if value.Kind != yaml.MappingNode {
+				l.report(key.Line, name, CodeInvalidTest, SeverityError, "given must map variable names to values")
+				continue
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				scope[value.Content[j].Value] = true
+			}
+			continue
+		case "when":
+			when = value
+			continue
+		case "then":
+			then = value
+			continue
+		case "llm_verify":
+			continue
+		}
+
+		d := l.report(key.Line, name, CodeUnknownKey, SeverityWarning, "unknown key %q is ignored", key.Value)
+		if suggestions := nearestNames(key.Value, allowed); len(suggestions) > 0 {
+			d.DidYouMean = suggestions
+			d.Message = withSuggestions(d.Message, suggestions)
+		}
+	}
+
+	severity := SeverityError
+	if !strict {
+		severity = SeverityWarning
+	}
+	for _, phase := range []struct {
+		name  string
+		steps *yaml.Node
+	}{{"when", when}, {"then", then}} {
+		if phase.steps == nil || (phase.steps.Kind == yaml.SequenceNode && len(phase.steps.Content) == 0) {
+			l.report(line, name, CodeInvalidTest, severity, "the test has no '%s' steps", phase.name)
+			continue
+		}
+		if !isScalarSequence(phase.steps) {
+			l.report(phase.steps.Line, name, CodeInvalidTest, SeverityError, "'%s' must be a list of steps", phase.name)
+			continue
+		}
+		for _, step := range phase.steps.Content {
+			if phase.name == "when" {
+				l.lintStatement(name, step, scope)
+			} else {
+				l.lintExpectation(name, step, scope)
+			}
+		}
+	}
+}
+
+// lintStatement checks a when step: var = expr
+func (l *linter) lintStatement(test string, step *yaml.Node, scope map[string]bool) {
+	stmt := strings.TrimSpace(step.Value)
+	if strings.HasPrefix(stmt, "expect:") {
+		l.report(step.Line, test, CodeInvalidStatement, SeverityError, "expectation in 'when': move %q to 'then'", stmt)
+		return
+	}
+
+	parts := strings.Split(stmt, "=")
+	if len(parts) != 2 {
+		l.report(step.Line, test, CodeInvalidStatement, SeverityError, "%q is not a statement (expected: var = expr)", stmt)
+		return
+	}
+	target, expr := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	l.lintExpr(test, step.Line, expr, scope)
+
+	object, _, isProperty := strings.Cut(target, ".")
+	object = strings.TrimSpace(object)
+	switch {
+	case !identifierPattern.MatchString(object):
+		l.report(step.Line, test, CodeInvalidStatement, SeverityError, "cannot assign to %q", target)
+	case isProperty:
+		l.lintVariable(test, step.Line, object, scope)
+	default:
+		scope[target] = true
+	}
+}
+
+// lintExpectation checks a then step: expect: <expr> <op> <expr>
+func (l *linter) lintExpectation(test string, step *yaml.Node, scope map[string]bool) {
+	text := strings.TrimSpace(step.Value)
+	body, ok := strings.CutPrefix(text, "expect:")
+	if !ok {
+		l.report(step.Line, test, CodeInvalidExpectation, SeverityError, "missing 'expect:' in %q (expected: expect: <expr> <op> <expr>)", text)
+		return
+	}
+	body = strings.TrimSpace(body)
+
+	operators := []string{" contains ", " startsWith ", " endsWith ", "==", "!=", ">=", "<=", ">", "<"}
+	for _, op := range operators {
+		if !strings.Contains(body, op) {
+			continue
+		}
+		parts := strings.Split(body, op)
+		if len(parts) != 2 {
+			l.report(step.Line, test, CodeInvalidExpectation, SeverityError, "invalid expectation: %s", body)
+			return
+		}
+		l.lintExpr(test, step.Line, parts[0], scope)
+		l.lintExpr(test, step.Line, parts[1], scope)
+		return
+	}
+	l.report(step.Line, test, CodeInvalidExpectation, SeverityError, "no comparison operator found in: %s", body)
+}
+
+// lintExpr checks an expression the way Context.Eval evaluates it
+func (l *linter) lintExpr(test string, line int, expr string, scope map[string]bool) {
+	expr = strings.TrimSpace(expr)
+	if _, err := strconv.ParseFloat(expr, 64); err == nil || expr == "true" || expr == "false" {
+		return
+	}
+	if strings.HasPrefix(expr, `"`) && strings.HasSuffix(expr, `"`) {
+		return
+	}
+
+	if strings.Contains(expr, "(") && strings.HasSuffix(expr, ")") {
+		open := strings.Index(expr, "(")
+		name := strings.TrimSpace(expr[:open])
+		if !identifierPattern.MatchString(name) {
+			l.report(line, test, CodeInvalidExpression, SeverityError, "invalid function call: %s", expr)
+			return
+		}
+		l.lintFunction(test, line, name)
+		if args := strings.TrimSpace(expr[open+1 : len(expr)-1]); args != "" {
+			for _, arg := range strings.Split(args, ",") {
+				l.lintExpr(test, line, arg, scope)
+			}
+		}
+		return
+	}
+
+	name, _, _ := strings.Cut(expr, ".")
+	name = strings.TrimSpace(name)
+	if !identifierPattern.MatchString(name) {
+		l.report(line, test, CodeInvalidExpression, SeverityError, "unsupported expression %q (use a literal, variable, property or function call)", expr)
+		return
+	}
+	l.lintVariable(test, line, name, scope)
+}
+
+// lintVariable reports a variable that is used before it is set
+func (l *linter) lintVariable(test string, line int, name string, scope map[string]bool) {
+	if scope[name] {
+		return
+	}
+
+	names := make([]string, 0, len(scope))
+	for defined := range scope {
+		names = append(names, defined)
+	}
+	suggestions := nearestNames(name, names)
+	message := "undefined variable: " + name + " (set it in 'given' or in an earlier 'when' step)"
+	if len(suggestions) > 0 {
+		message = withSuggestions("undefined variable: "+name, suggestions)
+	}
+	d := l.report(line, test, CodeUndefinedVar, SeverityError, "%s", message)
+	d.DidYouMean = suggestions
+}
+
+// lintFunction reports a call to a function that is neither built in nor exported. When
+// modules were scanned the function may still exist, so the call is only a warning.
+func (l *linter) lintFunction(test string, line int, name string) {
+	if l.functions == nil || l.functions[name] {
+		return
+	}
+
+	candidates := make([]string, 0, len(l.functions))
+	for function := range l.functions {
+		candidates = append(candidates, function)
+	}
+	suggestions := nearestNames(name, candidates)
+	message := "unknown function: " + name + " (not a built-in, no external modules configured)"
+	severity := SeverityError
+	if l.scanned {
+		message = "unknown function: " + name + " (not a built-in or found in the configured modules)"
+		severity = SeverityWarning
+	}
+	if len(suggestions) > 0 {
+		message = withSuggestions("unknown function: "+name, suggestions)
+	}
+	d := l.report(line, test, CodeUnknownFunc, severity, "%s", message)
+	d.DidYouMean = suggestions
+}
+
+// mappingValue returns the value of key in a mapping node (nil if absent)
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// isScalarSequence reports whether node is a list of plain values
+func isScalarSequence(node *yaml.Node) bool {
+	if node.Kind != yaml.SequenceNode {
+		return false
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// diagnosticSummary renders diagnostics as "line severity code" for comparison
+func diagnosticSummary(diagnostics []Diagnostic) []string {
+	var lines []string
+	for _, d := range diagnostics {
+		lines = append(lines, fmt.Sprintf("%d %s %s", d.Line, d.Severity, d.Code))
+	}
+	return lines
+}
+
+func TestLintFile(t *testing.T) {
+	source := `# Cart tests
+description: cart tests
+"adds items":
+  confidance: 0.9
+  given:
+    item_count: 1
+  when:
+    - "expect: item_count == 1"
+    - total = add(itemCount, 1)
+    - sum = 1 + 1
+  then:
+    - total == 2
+    - "expect: totl == 2"
+    - "expect: chekout(total) == 2"
+"adds items":
+  when:
+    - total = add(1, 2)
+  then:
+    - "expect: total == 3"
+"meta":
+  owner: payments
+`
+	functions := map[string]bool{"add": true, "checkout": true}
+	diagnostics := lintFile("cart.vyb", []byte(source), functions, false)
+
+	want := []string{
+		"2 error VYB_UNKNOWN_KEY",
+		"4 warning VYB_UNKNOWN_KEY",
+		"8 error VYB_INVALID_STATEMENT",
+		"9 error VYB_UNDEFINED_VAR",
+		"10 error VYB_INVALID_EXPRESSION",
+		"12 error VYB_INVALID_EXPECTATION",
+		"13 error VYB_UNDEFINED_VAR",
+		"14 error VYB_UNKNOWN_FUNC",
+		"15 error VYB_DUPLICATE_TEST",
+		"20 warning VYB_UNKNOWN_KEY",
+	}
+	if got := diagnosticSummary(diagnostics); !reflect.DeepEqual(got, want) {
+		t.Fatalf("diagnostics = %v\nwant %v", got, want)
+	}
+
+	suggestions := map[int][]string{4: {"confidence"}, 9: {"item_count"}, 13: {"total"}, 14: {"checkout"}}
+	for _, d := range diagnostics {
+		if want := suggestions[d.Line]; !reflect.DeepEqual(d.DidYouMean, want) {
+			t.Errorf("line %d: did_you_mean = %v, want %v", d.Line, d.DidYouMean, want)
+		}
+		if d.Line == 9 && d.Test != "adds items" {
+			t.Errorf("line 9: test = %q", d.Test)
+		}
+	}
+}
+
+func TestLintFileClean(t *testing.T) {
+	clean := `"adds":
+  confidence: 0.9
+  tags: [math]
+  given:
+    cart: {items: 1}
+  when:
+    - cart.items = add(cart.items, 1)
+    - total = cart.items
+  then:
+    - "expect: total == 2"
+    - "expect: concat(\"a\", \"b\") contains \"a\""
+`
+	if diagnostics := lintFile("clean.vyb", []byte(clean), map[string]bool{"add": true, "concat": true}, false); len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %+v", diagnostics)
+	}
+
+	// Calls aren't checked when the modules' functions are unknown
+	unknown := `"adds":
+  when:
+    - total = sum(1, 2)
+  then:
+    - "expect: total == 3"
+`
+	if diagnostics := lintFile("unknown.vyb", []byte(unknown), nil, false); len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics without known functions, got %+v", diagnostics)
+	}
+}
+
+func TestLintFileStructure(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{"invalid YAML", "adds: [\n", []string{"1 error VYB_PARSE_ERROR"}},
+		{"not a mapping", "- adds\n", []string{"1 error VYB_PARSE_ERROR"}},
+		{"missing then", "adds:\n  when:\n    - x = add(1, 2)\n", []string{"1 error VYB_INVALID_TEST"}},
+		{"bad fields", "adds:\n  confidence: 1.5\n  retries: -1\n  tags: math\n  when:\n    - x = add(1, 2)\n  then:\n    - \"expect: x == 3\"\n",
+			[]string{"2 error VYB_INVALID_TEST", "3 error VYB_INVALID_TEST", "4 error VYB_INVALID_TEST"}},
+		{"legacy", "test:\n  name: adds\n  when:\n    - x = add(1, 2)\n  then:\n    - \"expect: y == 3\"\nextra: 1\n",
+			[]string{"6 error VYB_UNDEFINED_VAR", "7 warning VYB_UNKNOWN_KEY"}},
+	}
+	for _, tt := range tests {
+		diagnostics := lintFile("test.vyb", []byte(tt.source), map[string]bool{"add": true}, false)
+		if got := diagnosticSummary(diagnostics); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: diagnostics = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.WriteFile("cart.js", []byte("function checkout(items) { return items }\nmodule.exports = { checkout }\n"), 0644)
+	os.WriteFile("vyb.config.yaml", []byte("runtime: node\nmodules:\n  - ./cart.js\n"), 0644)
+	os.WriteFile("cart.js.vyb", []byte(`"checks out":
+  when:
+    - total = checkot(1)
+  then:
+    - "expect: total == 1"
+`), 0644)
+
+	// The module scan can miss exports, so an unknown function is only a warning
+	var out bytes.Buffer
+	if err := Lint(".", OutputJSON, &out); err != nil {
+		t.Errorf("Lint() error = %v, want only a warning for the unknown function", err)
+	}
+
+	var report LintReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if report.Summary != (LintSummary{Files: 1, Warnings: 1}) || report.Diagnostics[0].Code != CodeUnknownFunc ||
+		!reflect.DeepEqual(report.Diagnostics[0].DidYouMean, []string{"checkout"}) {
+		t.Errorf("report = %+v", report)
+	}
+
+	out.Reset()
+	os.WriteFile("cart.js.vyb", []byte(`"checks out":
+  when:
+    - total = checkout(1)
+  then:
+    - "expect: total == 1"
+`), 0644)
+	if err := Lint(".", OutputPretty, &out); err != nil || !strings.Contains(out.String(), "No problems found in 1 file(s)") {
+		t.Errorf("Lint = %v\n%s", err, out.String())
+	}
+}
+
+func TestExportedFunctions(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "cart.js"), []byte("exports.checkout = () => 1\n"), 0644)
+
+	functions, diagnostics := exportedFunctions(dir, &parser.Config{Modules: []string{"./cart.js"}})
+	if !functions["checkout"] || !functions["add"] || len(diagnostics) != 0 {
+		t.Errorf("functions = %v, diagnostics = %v", functions, diagnostics)
+	}
+
+	functions, diagnostics = exportedFunctions(dir, &parser.Config{Modules: []string{"./cart.js", "./missing.js"}})
+	if functions != nil || len(diagnostics) != 1 || diagnostics[0].Code != CodeModuleNotFound {
+		t.Errorf("functions = %v, diagnostics = %v", functions, diagnostics)
+	}
+}
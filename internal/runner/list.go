@@ -0,0 +1,166 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vybtest/vyb/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// TestList is every test found by vyb list, without running anything
+type TestList struct {
+	Files []ListedFile `json:"files" yaml:"files"`
+	Tests []ListedTest `json:"tests" yaml:"tests"`
+}
+
+// ListedFile is a test file with its number of tests and, when a previous run recorded
+// it, how long it took (for planning shards)
+type ListedFile struct {
+	File     string  `json:"file" yaml:"file"`
+	Tests    int     `json:"tests" yaml:"tests"`
+	Duration float64 `json:"duration_seconds,omitempty" yaml:"duration_seconds,omitempty"`
+	Error    string  `json:"error,omitempty" yaml:"error,omitempty"` // Why the file couldn't be parsed
+}
+
+// ListedTest is a test as written in its file
+type ListedTest struct {
+	Name       string   `json:"name" yaml:"name"`
+	File       string   `json:"file" yaml:"file"`
+	Line       int      `json:"line,omitempty" yaml:"line,omitempty"`
+	Tags       []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Confidence float64  `json:"confidence" yaml:"confidence"`
+	Functions  []string `json:"functions,omitempty" yaml:"functions,omitempty"` // Functions called in when/then
+}
+
+// List writes the tests matching pattern in the given format (pretty, json, or YAML for
+// anything else). Files are only parsed; no bridge is started. Returns an error after
+// writing the list when a file couldn't be parsed.
+func List(pattern string, format OutputFormat, out io.Writer) error {
+	list, err := listTests(pattern)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case OutputPretty:
+		writePrettyList(out, pattern, list)
+	case OutputJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(list); err != nil {
+			return err
+		}
+	default:
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(list); err != nil {
+			return err
+		}
+	}
+
+	if broken := list.brokenFiles(); broken > 0 {
+		return fmt.Errorf("failed to parse %d test file(s)", broken)
+	}
+	return nil
+}
+
+// listTests parses the test files matching pattern
+func listTests(pattern string) (*TestList, error) {
+	files, err := findTestFiles(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find test files: %w", err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	config, _ := parser.LoadConfig(cwd) // A broken config only hides the durations
+	durations := loadDurations(durationsPath(cwd, config, ""))
+
+	list := &TestList{Files: []ListedFile{}, Tests: []ListedTest{}}
+	for _, file := range files {
+		listed := ListedFile{File: file, Duration: durations[filepath.ToSlash(file)]}
+
+		testFile, err := parser.Parse(file)
+		if err != nil {
+			listed.Error = err.Error()
+			list.Files = append(list.Files, listed)
+			continue
+		}
+
+		listed.Tests = len(testFile.Tests)
+		list.Files = append(list.Files, listed)
+		for i := range testFile.Tests {
+			test := &testFile.Tests[i]
+			list.Tests = append(list.Tests, ListedTest{
+				Name:       test.Name,
+				File:       file,
+				Line:       test.Line,
+				Tags:       test.Tags,
+				Confidence: test.Confidence,
+				Functions:  calledFunctions(test),
+			})
+		}
+	}
+	return list, nil
+}
+
+// brokenFiles counts the files that couldn't be parsed
+func (l *TestList) brokenFiles() int {
+	broken := 0
+	for _, file := range l.Files {
+		if file.Error != "" {
+			broken++
+		}
+	}
+	return broken
+}
+
+// writePrettyList prints the tests grouped by file, one aligned row per test
+func writePrettyList(out io.Writer, pattern string, list *TestList) {
+	if len(list.Files) == 0 {
+		fmt.Fprintln(out, "No test files found matching pattern:", pattern)
+		return
+	}
+
+	for _, file := range list.Files {
+		fmt.Fprintf(out, "%s%s%s", colorCyan, file.File, colorReset)
+		if file.Error != "" {
+			fmt.Fprintf(out, "\n  %s❌ %s%s\n\n", colorRed, file.Error, colorReset)
+			continue
+		}
+		fmt.Fprintf(out, " %s(%d test(s)", colorGray, file.Tests)
+		if file.Duration > 0 {
+			fmt.Fprintf(out, ", %v last run", time.Duration(file.Duration*float64(time.Second)).Round(time.Millisecond))
+		}
+		fmt.Fprintf(out, ")%s\n", colorReset)
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, test := range list.Tests {
+			if test.File != file.File {
+				continue
+			}
+			tags := ""
+			if len(test.Tags) > 0 {
+				tags = "[" + strings.Join(test.Tags, ", ") + "]"
+			}
+			calls := ""
+			if len(test.Functions) > 0 {
+				calls = "calls " + strings.Join(test.Functions, ", ")
+			}
+			fmt.Fprintf(w, "  %d\t%s\t%.2f\t%s\t%s\n", test.Line, test.Name, test.Confidence, tags, calls)
+		}
+		w.Flush()
+		fmt.Fprintln(out)
+	}
+
+	fmt.Fprintf(out, "%s%d test(s) in %d file(s)%s\n", colorBold, len(list.Tests), len(list.Files)-list.brokenFiles(), colorReset)
+}
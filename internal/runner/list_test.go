@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestListTests(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.WriteFile("calc.js.vyb", []byte(`# Calculator
+adds:
+  confidence: 0.9
+  tags: [math, fast]
+  when:
+    - x = add(1, 2)
+  then:
+    - "expect: round(x) == 3"
+subtracts:
+  when:
+    - x = sub(3, 2)
+  then:
+    - "expect: x == 1"
+`), 0644)
+	os.WriteFile("broken.vyb", []byte("adds: [\n"), 0644)
+	os.WriteFile("vyb.config.yaml", []byte("durations: ci/durations.json\n"), 0644)
+	saveDurations(filepath.Join(dir, "ci", "durations.json"), map[string]float64{"calc.js.vyb": 1.5})
+
+	var out bytes.Buffer
+	err := List(".", OutputJSON, &out)
+	if err == nil || !strings.Contains(err.Error(), "1 test file") {
+		t.Errorf("Expected an error for the broken file, got %v", err)
+	}
+
+	var list TestList
+	if err := json.Unmarshal(out.Bytes(), &list); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(list.Tests) != 2 {
+		t.Fatalf("tests = %+v", list.Tests)
+	}
+	want := ListedTest{Name: "adds", File: "calc.js.vyb", Line: 2, Tags: []string{"math", "fast"}, Confidence: 0.9, Functions: []string{"add", "round"}}
+	if !reflect.DeepEqual(list.Tests[0], want) {
+		t.Errorf("tests[0] = %+v, want %+v", list.Tests[0], want)
+	}
+	if list.Tests[1].Confidence != 1.0 || list.Tests[1].Tags != nil {
+		t.Errorf("tests[1] = %+v", list.Tests[1])
+	}
+
+	files := make(map[string]ListedFile)
+	for _, file := range list.Files {
+		files[file.File] = file
+	}
+	if f := files["calc.js.vyb"]; f.Tests != 2 || f.Duration != 1.5 || f.Error != "" {
+		t.Errorf("calc.js.vyb = %+v", f)
+	}
+	if f := files["broken.vyb"]; f.Error == "" {
+		t.Errorf("broken.vyb = %+v", f)
+	}
+
+	out.Reset()
+	List("calc.js.vyb", OutputPretty, &out)
+	for _, want := range []string{"(2 test(s), 1.5s last run)", "[math, fast]", "calls add, round", "2 test(s) in 1 file(s)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("pretty output missing %q:\n%s", want, out.String())
+		}
+	}
+}
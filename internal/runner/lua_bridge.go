@@ -13,6 +13,8 @@ import (
 
 // LuaBridge handles executing external Lua functions
 type LuaBridge struct {
+	processTracker
+	functions  functionList
 	config     *parser.Config
 	bridgeCode string
 }
@@ -41,12 +43,19 @@ func (lb *LuaBridge) Call(functionName string, args []interface{}) (interface{},
 		args = []interface{}{}
 	}
 
-	// Create request payload
-	request := map[string]interface{}{
+	return lb.send(map[string]interface{}{
 		"function": functionName,
 		"args":     args,
-	}
+	})
+}
 
+// Functions lists the functions exported by the configured modules
+func (lb *LuaBridge) Functions() ([]string, error) {
+	return lb.functions.get(lb.send)
+}
+
+// send runs the bridge script with one request and returns its result
+func (lb *LuaBridge) send(request map[string]interface{}) (interface{}, error) {
 	requestJSON, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -62,42 +71,33 @@ func (lb *LuaBridge) Call(functionName string, args []interface{}) (interface{},
 
 	// Execute Lua with the bridge script
 	cmd := exec.Command("lua", bridgeFile, string(requestJSON))
-	output, err := cmd.CombinedOutput()
+	output, err := lb.run(cmd)
+	response, ok := decodeBridgeResponse(output)
 
 	// Save the first error for better debugging
 	firstErr := err
 	firstOutput := output
 
-	if err != nil {
+	if !ok && err != nil {
 		// Try lua54, lua5.4, lua5.3, lua5.2, luajit if 'lua' not found
 		for _, luaCmd := range []string{"lua54", "lua5.4", "lua5.3", "lua5.2", "luajit"} {
 			cmd = exec.Command(luaCmd, bridgeFile, string(requestJSON))
-			output, err = cmd.CombinedOutput()
-			if err == nil {
+			output, err = lb.run(cmd)
+			response, ok = decodeBridgeResponse(output)
+			if ok || err == nil {
 				break
 			}
 		}
-		if err != nil {
+		if !ok && err != nil {
 			// Show the first error (from 'lua') since that's the preferred command
-			return nil, fmt.Errorf("lua execution failed (tried: lua, lua54, lua5.4, lua5.3, lua5.2, luajit):\nFirst error: %w\nOutput: %s", firstErr, string(firstOutput))
+			return nil, bridgeCrash(firstErr, firstOutput, "lua execution failed (tried: lua, lua54, lua5.4, lua5.3, lua5.2, luajit):\nFirst error: %v\nOutput: %s", firstErr, string(firstOutput))
 		}
 	}
-
-	// Parse response
-	var response struct {
-		Result interface{} `json:"result"`
-		Error  string      `json:"error"`
-	}
-
-	if err := json.Unmarshal(output, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse lua response: %w\nOutput: %s", err, string(output))
+	if !ok {
+		return nil, bridgeCrash(nil, output, "failed to parse lua response\nOutput: %s", string(output))
 	}
 
-	if response.Error != "" {
-		return nil, fmt.Errorf("external function error: %s", response.Error)
-	}
-
-	return response.Result, nil
+	return response.value()
 }
 
 // generateLuaBridgeScript creates the Lua bridge script that requires modules and calls functions
@@ -139,6 +139,12 @@ end
 if not json then
     json = {}
 
+    -- JSON strings can't hold quotes, backslashes or control characters unescaped
+    local escapes = { ['"'] = '\\"', ['\\'] = '\\\\', ['\n'] = '\\n', ['\r'] = '\\r', ['\t'] = '\\t' }
+    local function escape(c)
+        return escapes[c] or string.format('\\u%04x', c:byte())
+    end
+
     function json.encode(obj)
         if type(obj) == "table" then
             local result = "{"
@@ -146,16 +152,13 @@ if not json then
             for k, v in pairs(obj) do
                 if not first then result = result .. "," end
                 first = false
-                if type(k) == "string" then
-                    result = result .. '"' .. k .. '":'
-                else
-                    result = result .. '"' .. tostring(k) .. '":'
-                end
+                result = result .. json.encode(tostring(k)) .. ':'
                 result = result .. json.encode(v)
             end
             return result .. "}"
         elseif type(obj) == "string" then
-            return '"' .. obj:gsub('"', '\\"') .. '"'
+            local escaped = obj:gsub('[%c"\\]', escape)
+            return '"' .. escaped .. '"'
         elseif type(obj) == "number" or type(obj) == "boolean" then
             return tostring(obj)
         elseif obj == nil then
@@ -248,6 +251,16 @@ if not success then
     os.exit(1)
 end
 
+-- List the exported functions (used for "did you mean" suggestions)
+if request["list"] then
+    local names = {}
+    for name in pairs(functions) do
+        table.insert(names, name)
+    end
+    print(json.encode({result = names}))
+    os.exit(0)
+end
+
 -- Find and call the function
 local fn = functions[request["function"]]
 
@@ -259,7 +272,7 @@ if not fn then
     local error_msg = string.format("Function not found: %s. Available: %s",
                                     request["function"],
                                     table.concat(available, ", "))
-    print(json.encode({error = error_msg}))
+    print(json.encode({error = error_msg, kind = "not_found"}))
     os.exit(1)
 end
 
@@ -269,13 +282,15 @@ if type(fn) ~= "function" then
 end
 
 -- Call the function with args
-local status, result = pcall(function()
+local status, result = xpcall(function()
     return fn(table.unpack(request["args"] or {}))
+end, function(err)
+    return {message = tostring(err), stack = debug.traceback(tostring(err), 2)}
 end)
 
 if not status then
     -- Function threw an error
-    print(json.encode({error = tostring(result)}))
+    print(json.encode({error = result.message, stack = result.stack}))
     os.exit(1)
 end
 
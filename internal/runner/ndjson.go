@@ -0,0 +1,197 @@
+package runner
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// ndjsonSchemaVersion is bumped whenever an event field is removed or changes meaning.
+// Adding fields is backwards compatible and does not change the version.
+const ndjsonSchemaVersion = 1
+
+// eventHeader is shared by every NDJSON event
+type eventHeader struct {
+	Event string `json:"event"` // run_start, file_start, test_start, step, test_end, file_end, run_end
+	Time  string `json:"time"`  // RFC 3339 timestamp with nanoseconds
+}
+
+type runStartEvent struct {
+	eventHeader
+	SchemaVersion int    `json:"schema_version"`
+	Version       string `json:"version"`
+	Files         int    `json:"files"`
+	Seed          int64  `json:"seed,omitempty"`
+	Shard         string `json:"shard,omitempty"`
+}
+
+type fileEvent struct {
+	eventHeader
+	File string `json:"file"`
+}
+
+type testStartEvent struct {
+	eventHeader
+	File       string  `json:"file"`
+	Test       string  `json:"test"`
+	Confidence float64 `json:"confidence"`
+}
+
+type stepEvent struct {
+	eventHeader
+	File     string  `json:"file"`
+	Test     string  `json:"test"`
+	Phase    string  `json:"phase"` // "when" or "then"
+	Index    int     `json:"index"` // 0-based position within the phase
+	Text     string  `json:"text"`
+	Status   string  `json:"status"` // "pass" or "fail"
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_seconds"`
+	Attempt  int     `json:"attempt"`
+}
+
+type testEndEvent struct {
+	eventHeader
+	File       string      `json:"file"`
+	Test       string      `json:"test"`
+	Status     string      `json:"status"` // "pass", "fail", "flaky" or "not_run"
+	Error      string      `json:"error,omitempty"`
+	Code       string      `json:"code,omitempty"`
+	DidYouMean []string    `json:"did_you_mean,omitempty"`
+	Duration   float64     `json:"duration_seconds"`
+	Confidence float64     `json:"confidence"`
+	Attempts   int         `json:"attempts,omitempty"`
+	FailedStep string      `json:"failed_step,omitempty"`
+	StepIndex  *int        `json:"step_index,omitempty"`
+	Actual     interface{} `json:"actual,omitempty"`
+	Expected   interface{} `json:"expected,omitempty"`
+	Diff       []string    `json:"diff,omitempty"`
+	Hints      []string    `json:"hints,omitempty"`
+}
+
+type fileEndEvent struct {
+	eventHeader
+	File    string      `json:"file"`
+	Summary FileSummary `json:"summary"`
+}
+
+type runEndEvent struct {
+	eventHeader
+	Summary TestSummary `json:"summary"`
+}
+
+// ndjsonReporter streams one JSON event per line as the run progresses
+type ndjsonReporter struct {
+	out io.Writer
+}
+
+func (r *ndjsonReporter) RunStart(info RunInfo) {
+	r.writeEvent(runStartEvent{
+		eventHeader:   newEventHeader("run_start"),
+		SchemaVersion: ndjsonSchemaVersion,
+		Version:       Version,
+		Files:         info.Files,
+		Seed:          info.Seed,
+		Shard:         info.Shard,
+	})
+}
+
+func (r *ndjsonReporter) FileStart(file string) {
+	r.writeEvent(fileEvent{eventHeader: newEventHeader("file_start"), File: file})
+}
+
+func (r *ndjsonReporter) TestStart(file string, test *parser.Test) {
+	r.writeEvent(testStartEvent{
+		eventHeader: newEventHeader("test_start"),
+		File:        file,
+		Test:        test.Name,
+		Confidence:  test.Confidence,
+	})
+}
+
+func (r *ndjsonReporter) Step(file string, test *parser.Test, step StepResult) {
+	r.writeEvent(newStepEvent(newEventHeader("step"), file, test, step))
+}
+
+func (r *ndjsonReporter) TestEnd(report TestReport) {
+	r.writeEvent(newTestEndEvent(newEventHeader("test_end"), report))
+}
+
+func (r *ndjsonReporter) FileEnd(file string, summary FileSummary) {
+	r.writeEvent(fileEndEvent{eventHeader: newEventHeader("file_end"), File: file, Summary: summary})
+}
+
+// Notice is not part of the event stream; notices go to the human-readable reporters
+func (r *ndjsonReporter) Notice(level NoticeLevel, message string) {}
+
+func (r *ndjsonReporter) Finish(summary TestSummary) error {
+	return r.writeEvent(runEndEvent{eventHeader: newEventHeader("run_end"), Summary: summary})
+}
+
+// newEventHeader stamps an event with its type and the current time
+func newEventHeader(event string) eventHeader {
+	return eventHeader{Event: event, Time: time.Now().Format(time.RFC3339Nano)}
+}
+
+// writeEvent writes one event as a single line with a single write, so consumers
+// see it immediately and a crash never leaves a partial run unreported
+func (r *ndjsonReporter) writeEvent(event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = r.out.Write(append(data, '\n'))
+	return err
+}
+
+// newStepEvent converts a step result into its event
+func newStepEvent(header eventHeader, filename string, test *parser.Test, step StepResult) stepEvent {
+	status := "pass"
+	if !step.Passed {
+		status = "fail"
+	}
+
+	return stepEvent{
+		eventHeader: header,
+		File:        filename,
+		Test:        test.Name,
+		Phase:       step.Phase,
+		Index:       step.Index,
+		Text:        step.Text,
+		Status:      status,
+		Error:       step.Error,
+		Duration:    float64(step.Duration) / 1e9,
+		Attempt:     step.Attempt,
+	}
+}
+
+// newTestEndEvent converts a finished test into its event, with suggest-mode details for failures
+func newTestEndEvent(header eventHeader, report TestReport) testEndEvent {
+	result := report.Result
+	event := testEndEvent{
+		eventHeader: header,
+		File:        report.File,
+		Test:        result.Name,
+		Status:      report.Status,
+		Error:       result.Error,
+		Code:        result.Code,
+		DidYouMean:  result.Suggestions,
+		Duration:    float64(result.Duration) / 1e9,
+		Confidence:  result.Confidence,
+		Attempts:    report.Attempts,
+	}
+
+	if report.Status == "fail" && report.Test != nil {
+		suggestResult := newSuggestResult(report)
+		event.FailedStep = suggestResult.FailedStep
+		event.StepIndex = suggestResult.StepIndex
+		event.Actual = suggestResult.Actual
+		event.Expected = suggestResult.Expected
+		event.Diff = suggestResult.Diff
+		event.Hints = suggestResult.Hints
+	}
+
+	return event
+}
@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNDJSONEventStream(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.WriteFile("a.vyb", []byte(`adds:
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 2"
+breaks:
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 3"
+`), 0644)
+	os.WriteFile("b.vyb", []byte(`subtracts:
+  when:
+    - x = add(2, -1)
+  then:
+    - "expect: x == 1"
+`), 0644)
+
+	output := filepath.Join("out", "events.ndjson")
+	opts := Options{Reporters: []ReporterSpec{{Format: OutputNDJSON, Output: output}}, MaxFailures: 1}
+	if err := runFiles([]string{"a.vyb", "b.vyb"}, opts); err == nil {
+		t.Fatal("runFiles() error = nil, want failed tests")
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var events []map[string]interface{}
+	var sequence []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Line is not a JSON object: %v\n%s", err, scanner.Text())
+		}
+		if _, err := time.Parse(time.RFC3339Nano, event["time"].(string)); err != nil {
+			t.Errorf("Event %v has an invalid time: %v", event["event"], err)
+		}
+
+		name := event["event"].(string)
+		if test, ok := event["test"]; ok && name != "step" {
+			name += " " + test.(string)
+		} else if file, ok := event["file"]; ok && name != "step" {
+			name += " " + file.(string)
+		}
+		sequence = append(sequence, name)
+		events = append(events, event)
+	}
+
+	want := []string{
+		"run_start",
+		"file_start a.vyb",
+		"test_start adds", "step", "step", "test_end adds",
+		"test_start breaks", "step", "step", "test_end breaks",
+		"file_end a.vyb",
+		"file_start b.vyb",
+		"test_end subtracts",
+		"file_end b.vyb",
+		"run_end",
+	}
+	if !reflect.DeepEqual(sequence, want) {
+		t.Fatalf("events:\n%s\nwant:\n%s", strings.Join(sequence, "\n"), strings.Join(want, "\n"))
+	}
+
+	runStart := events[0]
+	if runStart["schema_version"] != float64(ndjsonSchemaVersion) || runStart["version"] != Version || runStart["files"] != 2.0 {
+		t.Errorf("run_start = %v", runStart)
+	}
+	if _, ok := runStart["seed"]; ok {
+		t.Errorf("run_start should omit seed when not shuffled: %v", runStart)
+	}
+
+	step := events[8]
+	if step["phase"] != "then" || step["index"] != 0.0 || step["text"] != "expect: x == 3" || step["status"] != "fail" || step["attempt"] != 1.0 {
+		t.Errorf("failed step = %v", step)
+	}
+
+	pass := events[5]
+	if pass["status"] != "pass" || pass["confidence"] == nil {
+		t.Errorf("test_end adds = %v", pass)
+	}
+	for _, field := range []string{"error", "failed_step", "step_index", "actual", "expected", "hints"} {
+		if _, ok := pass[field]; ok {
+			t.Errorf("Passing test_end should omit %s: %v", field, pass)
+		}
+	}
+
+	fail := events[9]
+	if fail["status"] != "fail" || fail["failed_step"] != "then" || fail["step_index"] != 0.0 ||
+		fail["actual"] != 2.0 || fail["expected"] != 3.0 || fail["code"] != string(CodeAssertMismatch) {
+		t.Errorf("test_end breaks = %v", fail)
+	}
+
+	if notRun := events[12]; notRun["status"] != "not_run" || notRun["file"] != "b.vyb" {
+		t.Errorf("test_end subtracts = %v", notRun)
+	}
+
+	fileEnd := events[10]["summary"].(map[string]interface{})
+	if fileEnd["total"] != 2.0 || fileEnd["passed"] != 1.0 || fileEnd["failed"] != 1.0 {
+		t.Errorf("file_end a.vyb summary = %v", fileEnd)
+	}
+	runEnd := events[14]["summary"].(map[string]interface{})
+	if runEnd["total"] != 3.0 || runEnd["failed"] != 1.0 || runEnd["not_run"] != 1.0 {
+		t.Errorf("run_end summary = %v", runEnd)
+	}
+}
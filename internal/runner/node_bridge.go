@@ -13,6 +13,8 @@ import (
 
 // NodeBridge handles executing external JavaScript/TypeScript functions via Node.js
 type NodeBridge struct {
+	processTracker
+	functions  functionList
 	config     *parser.Config
 	bridgeCode string
 }
@@ -36,12 +38,24 @@ func NewNodeBridge(config *parser.Config) (*NodeBridge, error) {
 
 // Call executes an external function via Node.js
 func (nb *NodeBridge) Call(functionName string, args []interface{}) (interface{}, error) {
-	// Create request payload
-	request := map[string]interface{}{
+	// Ensure args is never nil (use empty array instead)
+	if args == nil {
+		args = []interface{}{}
+	}
+
+	return nb.send(map[string]interface{}{
 		"function": functionName,
 		"args":     args,
-	}
+	})
+}
 
+// Functions lists the functions exported by the configured modules
+func (nb *NodeBridge) Functions() ([]string, error) {
+	return nb.functions.get(nb.send)
+}
+
+// send runs the bridge script with one request and returns its result
+func (nb *NodeBridge) send(request map[string]interface{}) (interface{}, error) {
 	requestJSON, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -57,26 +71,14 @@ func (nb *NodeBridge) Call(functionName string, args []interface{}) (interface{}
 
 	// Execute Node.js with the bridge script
 	cmd := exec.Command("node", bridgeFile, string(requestJSON))
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("node execution failed: %w\nOutput: %s", err, string(output))
-	}
-
-	// Parse response
-	var response struct {
-		Result interface{} `json:"result"`
-		Error  string      `json:"error"`
+	output, err := nb.run(cmd)
+	if response, ok := decodeBridgeResponse(output); ok {
+		return response.value()
 	}
-
-	if err := json.Unmarshal(output, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse node response: %w\nOutput: %s", err, string(output))
-	}
-
-	if response.Error != "" {
-		return nil, fmt.Errorf("external function error: %s", response.Error)
+	if err != nil {
+		return nil, bridgeCrash(err, output, "node execution failed: %v\nOutput: %s", err, string(output))
 	}
-
-	return response.Result, nil
+	return nil, bridgeCrash(nil, output, "failed to parse node response\nOutput: %s", string(output))
 }
 
 // generateBridgeScript creates the Node.js bridge script that imports modules and calls functions
@@ -107,12 +109,20 @@ const functions = {};
 // Read request from command line argument
 const request = JSON.parse(process.argv[2]);
 
+// List the exported functions (used for "did you mean" suggestions)
+if (request.list) {
+  console.log(JSON.stringify({ result: Object.keys(functions).filter(name => typeof functions[name] === 'function') }));
+  process.exit(0);
+}
+
 try {
   // Find and call the function
   const fn = functions[request.function];
 
   if (!fn) {
-    throw new Error('Function not found: ' + request.function + '. Available: ' + Object.keys(functions).join(', '));
+    const error = new Error('Function not found: ' + request.function + '. Available: ' + Object.keys(functions).join(', '));
+    error.kind = 'not_found';
+    throw error;
   }
 
   if (typeof fn !== 'function') {
@@ -123,11 +133,18 @@ try {
   const result = fn(...request.args);
 
   // Return result as JSON
-  console.log(JSON.stringify({ result: result }));
+  let response;
+  try {
+    response = JSON.stringify({ result: result });
+  } catch (error) {
+    error.kind = 'unserializable';
+    throw error;
+  }
+  console.log(response);
 
 } catch (error) {
   // Return error as JSON
-  console.log(JSON.stringify({ error: error.message }));
+  console.log(JSON.stringify({ error: error.message, stack: error.stack, kind: error.kind }));
   process.exit(1);
 }
 `
@@ -3,9 +3,9 @@ package runner
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"strings"
 
-	"github.com/vybtest/vyb/internal/parser"
 	"gopkg.in/yaml.v3"
 )
 
@@ -27,27 +27,55 @@ const (
 	OutputPretty  OutputFormat = "pretty"
 	OutputJSON    OutputFormat = "json"
 	OutputSuggest OutputFormat = "suggest" // Rich JSON with code snippets and AI hints
+	OutputJUnit   OutputFormat = "junit"   // JUnit XML for CI dashboards
+	OutputTAP     OutputFormat = "tap"     // TAP version 14, streamed as tests complete
+	OutputNDJSON  OutputFormat = "ndjson"  // Newline-delimited JSON events, one per line as they happen
+	OutputGitHub  OutputFormat = "github"  // GitHub Actions annotations and job summary
+	OutputHTML    OutputFormat = "html"    // Self-contained HTML page for sharing runs
+	OutputSARIF   OutputFormat = "sarif"   // SARIF 2.1.0 for code scanning and review tools
 )
 
+// ParseOutputFormat validates a --reporter name
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch format := OutputFormat(name); format {
+	case OutputPretty, OutputJSON, OutputSuggest, OutputJUnit, OutputTAP, OutputNDJSON, OutputGitHub, OutputHTML, OutputSARIF:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown reporter: %s (supported: pretty, json, suggest, junit, tap, ndjson, github, html, sarif)", name)
+	}
+}
+
 // TestSummary contains overall test run statistics
 type TestSummary struct {
-	Total              int     `json:"total" yaml:"total"`
-	Passed             int     `json:"passed" yaml:"passed"`
-	Failed             int     `json:"failed" yaml:"failed"`
-	Duration           float64 `json:"duration_seconds" yaml:"duration_seconds"`
-	AverageConfidence  float64 `json:"average_confidence" yaml:"average_confidence"`
-	MinConfidence      float64 `json:"min_confidence" yaml:"min_confidence"`
-	MaxConfidence      float64 `json:"max_confidence" yaml:"max_confidence"`
+	Total             int     `json:"total" yaml:"total"`
+	Passed            int     `json:"passed" yaml:"passed"`
+	Failed            int     `json:"failed" yaml:"failed"`
+	NotRun            int     `json:"not_run" yaml:"not_run"` // Tests skipped after hitting --max-failures
+	Flaky             int     `json:"flaky" yaml:"flaky"`     // Passed only after retrying (included in passed)
+	Duration          float64 `json:"duration_seconds" yaml:"duration_seconds"`
+	AverageConfidence float64 `json:"average_confidence" yaml:"average_confidence"`
+	MinConfidence     float64 `json:"min_confidence" yaml:"min_confidence"`
+	MaxConfidence     float64 `json:"max_confidence" yaml:"max_confidence"`
+	Seed              int64   `json:"seed,omitempty" yaml:"seed,omitempty"`   // Shuffle seed, reproduce with --seed
+	Shard             string  `json:"shard,omitempty" yaml:"shard,omitempty"` // Shard that was run (i/N)
+
+	ConfidenceGate *ConfidenceGateReport `json:"confidence_gate,omitempty" yaml:"confidence_gate,omitempty"` // Set when gates are configured
 }
 
 // JSONTestResult represents a test result in JSON format
 type JSONTestResult struct {
-	Name       string  `json:"name" yaml:"name"`
-	File       string  `json:"file" yaml:"file"`
-	Status     string  `json:"status" yaml:"status"` // "pass" or "fail"
-	Error      string  `json:"error,omitempty" yaml:"error,omitempty"`
-	Duration   float64 `json:"duration_seconds" yaml:"duration_seconds"`
-	Confidence float64 `json:"confidence" yaml:"confidence"`
+	Name       string   `json:"name" yaml:"name"`
+	File       string   `json:"file" yaml:"file"`
+	Status     string   `json:"status" yaml:"status"` // "pass", "fail", "flaky" or "not_run"
+	Error      string   `json:"error,omitempty" yaml:"error,omitempty"`
+	Code       string   `json:"code,omitempty" yaml:"code,omitempty"`                 // Stable error code
+	DidYouMean []string `json:"did_you_mean,omitempty" yaml:"did_you_mean,omitempty"` // Names that were probably meant
+	FailedStep string   `json:"failed_step,omitempty" yaml:"failed_step,omitempty"`   // Phase of the failed step
+	StepIndex  *int     `json:"step_index,omitempty" yaml:"step_index,omitempty"`     // 0-based index within the phase
+	Attempts   int      `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	Duration   float64  `json:"duration_seconds" yaml:"duration_seconds"`
+	Confidence float64  `json:"confidence" yaml:"confidence"`
+	Diff       []string `json:"diff,omitempty" yaml:"diff,omitempty"` // How actual differs from expected
 }
 
 // SuggestTestResult includes enhanced data for AI assistants
@@ -56,13 +84,29 @@ type SuggestTestResult struct {
 	File           string      `json:"file" yaml:"file"`
 	Status         string      `json:"status" yaml:"status"`
 	Error          string      `json:"error,omitempty" yaml:"error,omitempty"`
+	Code           string      `json:"code,omitempty" yaml:"code,omitempty"`                 // Stable error code, e.g. VYB_ASSERT_MISMATCH
+	DidYouMean     []string    `json:"did_you_mean,omitempty" yaml:"did_you_mean,omitempty"` // Names that were probably meant
 	Confidence     float64     `json:"confidence" yaml:"confidence"`
-	TestCode       string      `json:"test_code" yaml:"test_code"`                          // The actual test YAML
-	FailedStep     string      `json:"failed_step,omitempty" yaml:"failed_step,omitempty"`  // Which step failed (when, then)
-	Actual         interface{} `json:"actual,omitempty" yaml:"actual,omitempty"`            // Actual value when assertion fails
-	Expected       interface{} `json:"expected,omitempty" yaml:"expected,omitempty"`        // Expected value when assertion fails
-	Hints          []string    `json:"hints,omitempty" yaml:"hints,omitempty"`              // Pattern-based suggestions
+	Attempts       int         `json:"attempts,omitempty" yaml:"attempts,omitempty"`               // Runs needed when retried
+	TestCode       string      `json:"test_code" yaml:"test_code"`                                 // The actual test YAML
+	FailedStep     string      `json:"failed_step,omitempty" yaml:"failed_step,omitempty"`         // Which step failed (when, then)
+	StepIndex      *int        `json:"step_index,omitempty" yaml:"step_index,omitempty"`           // 0-based index of the failed step
+	Actual         interface{} `json:"actual,omitempty" yaml:"actual,omitempty"`                   // Actual value when assertion fails
+	Expected       interface{} `json:"expected,omitempty" yaml:"expected,omitempty"`               // Expected value when assertion fails
+	Diff           []string    `json:"diff,omitempty" yaml:"diff,omitempty"`                       // How actual differs from expected
+	Hints          []string    `json:"hints,omitempty" yaml:"hints,omitempty"`                     // Pattern-based suggestions
 	ConfidenceNote string      `json:"confidence_note,omitempty" yaml:"confidence_note,omitempty"` // Interpretation of confidence level
+	SameError      []string    `json:"same_error,omitempty" yaml:"same_error,omitempty"`           // Other tests failing identically (compact mode)
+
+	cause    string // The failure without the step text, to merge identical failures
+	operator string
+}
+
+// FlakyTest describes a test that passed only after retrying
+type FlakyTest struct {
+	Name     string `json:"name" yaml:"name"`
+	File     string `json:"file" yaml:"file"`
+	Attempts int    `json:"attempts" yaml:"attempts"`
 }
 
 // JSONOutput represents the complete JSON output
@@ -75,203 +119,295 @@ type JSONOutput struct {
 type SuggestOutput struct {
 	Summary TestSummary         `json:"summary" yaml:"summary"`
 	Tests   []SuggestTestResult `json:"tests" yaml:"tests"`
+	Flaky   []FlakyTest         `json:"flaky,omitempty" yaml:"flaky,omitempty"`     // Tests that passed only after retrying
+	Omitted int                 `json:"omitted,omitempty" yaml:"omitted,omitempty"` // Failures left out to fit --max-tokens
+	Full    string              `json:"full_results,omitempty" yaml:"full_results,omitempty"`
 	Message string              `json:"message" yaml:"message"` // Guidance for AI assistant
 }
 
-// Reporter handles test result output
-type Reporter struct {
-	format         OutputFormat
-	results        []JSONTestResult
-	suggestResults []SuggestTestResult
-	summary        TestSummary
-	confidenceSum  float64 // For calculating average
+// prettyReporter prints colored, human-readable results as tests complete
+type prettyReporter struct {
+	baseReporter
+	out io.Writer
 }
 
-// NewReporter creates a new reporter with the specified format
-func NewReporter(format OutputFormat) *Reporter {
-	return &Reporter{
-		format:         format,
-		results:        []JSONTestResult{},
-		suggestResults: []SuggestTestResult{},
-		confidenceSum:  0.0,
-		summary: TestSummary{
-			MinConfidence: 1.0, // Initialize to max, will be updated
-			MaxConfidence: 0.0, // Initialize to min, will be updated
-		},
+func (r *prettyReporter) RunStart(info RunInfo) {
+	fmt.Fprintf(r.out, "\n🌊 Vyb v%s\n\n", Version)
+	if info.Seed != 0 {
+		fmt.Fprintf(r.out, "%sShuffled with seed %d%s\n\n", colorGray, info.Seed, colorReset)
 	}
 }
 
-// ReportTestStart reports that a test file is starting
-func (r *Reporter) ReportTestStart(filename string) {
-	if r.format == OutputPretty {
-		fmt.Printf("%sRunning %s:%s\n", colorCyan, filename, colorReset)
+func (r *prettyReporter) Notice(level NoticeLevel, message string) {
+	switch level {
+	case NoticeWarning:
+		fmt.Fprintf(r.out, "%s%s%s\n", colorYellow, message, colorReset)
+	case NoticeError:
+		fmt.Fprintf(r.out, "%s❌ %s%s\n", colorRed, message, colorReset)
+	default:
+		fmt.Fprintf(r.out, "%s%s%s\n", colorGray, message, colorReset)
 	}
 }
 
-// ReportTestResult reports a single test result
-func (r *Reporter) ReportTestResult(filename string, result parser.TestResult) {
-	r.ReportTestResultWithTest(filename, result, nil)
+func (r *prettyReporter) FileStart(file string) {
+	fmt.Fprintf(r.out, "%sRunning %s:%s\n", colorCyan, file, colorReset)
 }
 
-// ReportTestResultWithTest reports a test result with the original test object (for --suggest mode)
-func (r *Reporter) ReportTestResultWithTest(filename string, result parser.TestResult, test *parser.Test) {
-	status := "fail"
-	if result.Passed {
-		status = "pass"
+func (r *prettyReporter) TestEnd(report TestReport) {
+	result := report.Result
+	switch {
+	case report.Status == "not_run":
+		return
+	case result.Flaky:
+		fmt.Fprintf(r.out, "  %s⚠️  %s%s %s(flaky: passed on attempt %d)%s\n",
+			colorYellow, result.Name, colorReset,
+			colorGray, result.Attempts, colorReset)
+	case result.Passed:
+		confidenceColor := colorGreen
+		if result.Confidence < 0.8 {
+			confidenceColor = colorYellow // Warn about low confidence
+		}
+		fmt.Fprintf(r.out, "  %s✅ %s%s %s(confident: %.2f)%s\n",
+			colorGreen, result.Name, colorReset,
+			confidenceColor, result.Confidence, colorReset)
+	default:
+		fmt.Fprintf(r.out, "  %s❌ %s%s\n", colorRed, result.Name, colorReset)
+		if result.Error != "" {
+			label := "Error"
+			if result.Code != "" {
+				label += " " + result.Code
+			}
+			if ref := stepRef(result); ref != "" {
+				label += " at " + ref
+			}
+			fmt.Fprintf(r.out, "     %s%s: %s%s\n", colorGray, label, result.Error, colorReset)
+		}
+		if diff := newResultDiff(result); diff != nil {
+			fmt.Fprint(r.out, diff.Pretty("     "))
+		} else if result.Actual != nil || result.Expected != nil {
+			fmt.Fprintf(r.out, "     %sExpected: %s%s\n", colorGreen, formatValue(result.Expected), colorReset)
+			fmt.Fprintf(r.out, "     %sActual:   %s%s\n", colorRed, formatValue(result.Actual), colorReset)
+		}
 	}
+}
 
-	jsonResult := JSONTestResult{
-		Name:       result.Name,
-		File:       filename,
-		Status:     status,
-		Error:      result.Error,
-		Duration:   float64(result.Duration) / 1e9,
-		Confidence: result.Confidence,
+func (r *prettyReporter) FileEnd(file string, summary FileSummary) {
+	fmt.Fprintln(r.out)
+}
+
+func (r *prettyReporter) Finish(summary TestSummary) error {
+	passColor := colorGreen
+	if summary.Failed > 0 {
+		passColor = colorRed
 	}
 
-	r.results = append(r.results, jsonResult)
-
-	// Create enhanced result for suggest mode
-	if r.format == OutputSuggest && test != nil {
-		suggestResult := SuggestTestResult{
-			Name:           result.Name,
-			File:           filename,
-			Status:         status,
-			Error:          result.Error,
-			Confidence:     result.Confidence,
-			TestCode:       formatTestCode(test),
-			Hints:          generateHints(test, result),
-			ConfidenceNote: getConfidenceNote(result.Confidence),
-		}
+	flaky := ""
+	if summary.Flaky > 0 {
+		flaky = fmt.Sprintf("%s%d flaky%s, ", colorYellow, summary.Flaky, colorReset+colorBold)
+	}
+
+	notRun := ""
+	if summary.NotRun > 0 {
+		notRun = fmt.Sprintf("%s%d not run%s, ", colorYellow, summary.NotRun, colorReset+colorBold)
+	}
+
+	fmt.Fprintf(r.out, "%sTests: %s%d passed%s, %s%s%d failed%s, %s%d total%s\n",
+		colorBold,
+		colorGreen, summary.Passed, colorReset+colorBold,
+		flaky,
+		passColor, summary.Failed, colorReset+colorBold,
+		notRun, summary.Total, colorReset)
 
-		if !result.Passed {
-			suggestResult.FailedStep = getFailedStep(result.Error)
-			suggestResult.Actual = result.Actual
-			suggestResult.Expected = result.Expected
+	// Confidence coverage
+	if summary.Passed+summary.Failed > 0 {
+		confidenceColor := colorGreen
+		if summary.AverageConfidence < 0.8 {
+			confidenceColor = colorYellow
 		}
 
-		r.suggestResults = append(r.suggestResults, suggestResult)
+		fmt.Fprintf(r.out, "%sConfidence: %savg %.2f%s, min %.2f, max %.2f%s\n",
+			colorBold,
+			confidenceColor, summary.AverageConfidence, colorReset+colorBold,
+			summary.MinConfidence, summary.MaxConfidence, colorReset)
+	}
+	if gate := summary.ConfidenceGate; gate != nil {
+		r.printConfidenceGate(gate)
 	}
 
-	// Update summary
-	r.summary.Total++
-	if result.Passed {
-		r.summary.Passed++
-	} else {
-		r.summary.Failed++
+	fmt.Fprintf(r.out, "Time: %.3fs\n", summary.Duration)
+
+	if summary.Seed != 0 {
+		fmt.Fprintf(r.out, "%sSeed: %d (reproduce with --seed %d)%s\n", colorGray, summary.Seed, summary.Seed, colorReset)
 	}
-	r.summary.Duration += jsonResult.Duration
 
-	// Track confidence metrics
-	r.confidenceSum += result.Confidence
-	if result.Confidence < r.summary.MinConfidence {
-		r.summary.MinConfidence = result.Confidence
+	return nil
+}
+
+// maxLowConfidenceListed limits the tests listed under a failed confidence gate
+const maxLowConfidenceListed = 10
+
+// printConfidenceGate prints the gate outcome and the tests pulling confidence down
+func (r *prettyReporter) printConfidenceGate(gate *ConfidenceGateReport) {
+	if gate.Passed {
+		fmt.Fprintf(r.out, "%sConfidence gate: passed%s\n", colorGreen, colorReset)
+		return
 	}
-	if result.Confidence > r.summary.MaxConfidence {
-		r.summary.MaxConfidence = result.Confidence
+
+	fmt.Fprintf(r.out, "%s%sConfidence gate failed:%s\n", colorBold, colorRed, colorReset)
+	for _, failure := range gate.Failures {
+		fmt.Fprintf(r.out, "  %s✗ %s%s\n", colorRed, failure, colorReset)
 	}
 
-	// Pretty output (real-time)
-	if r.format == OutputPretty {
-		if result.Passed {
-			confidenceColor := colorGreen
-			if result.Confidence < 0.8 {
-				confidenceColor = colorYellow // Warn about low confidence
-			}
-			fmt.Printf("  %s✅ %s%s %s(confident: %.2f)%s\n",
-				colorGreen, result.Name, colorReset,
-				confidenceColor, result.Confidence, colorReset)
-		} else {
-			fmt.Printf("  %s❌ %s%s\n", colorRed, result.Name, colorReset)
-			if result.Error != "" {
-				fmt.Printf("     %sError: %s%s\n", colorGray, result.Error, colorReset)
-			}
+	if len(gate.LowConfidence) == 0 {
+		return
+	}
+	fmt.Fprintf(r.out, "  Lowest confidence:\n")
+	for i, test := range gate.LowConfidence {
+		if i == maxLowConfidenceListed {
+			fmt.Fprintf(r.out, "    %s... and %d more%s\n", colorGray, len(gate.LowConfidence)-i, colorReset)
+			break
+		}
+		location := test.File
+		if test.Line > 0 {
+			location = fmt.Sprintf("%s:%d", test.File, test.Line)
 		}
+		fmt.Fprintf(r.out, "    %s%.2f%s  %s %s(%s, needs %.2f)%s\n",
+			colorYellow, test.Confidence, colorReset, test.Name, colorGray, location, test.Required, colorReset)
 	}
 }
 
-// ReportFileEnd reports that a test file has finished
-func (r *Reporter) ReportFileEnd() {
-	if r.format == OutputPretty {
-		fmt.Println()
-	}
+// jsonReporter writes the summary and all results as one JSON document at the end
+type jsonReporter struct {
+	baseReporter
+	out     io.Writer
+	results []JSONTestResult
 }
 
-// Finalize outputs the final summary
-func (r *Reporter) Finalize() error {
-	// Calculate average confidence
-	if r.summary.Total > 0 {
-		r.summary.AverageConfidence = r.confidenceSum / float64(r.summary.Total)
-	} else {
-		// No tests run - set to 0
-		r.summary.MinConfidence = 0.0
-		r.summary.MaxConfidence = 0.0
+func (r *jsonReporter) TestEnd(report TestReport) {
+	r.results = append(r.results, JSONTestResult{
+		Name:       report.Result.Name,
+		File:       report.File,
+		Status:     report.Status,
+		Error:      report.Result.Error,
+		Code:       report.Result.Code,
+		DidYouMean: report.Result.Suggestions,
+		FailedStep: report.Result.Step,
+		StepIndex:  failedStepIndex(report.Result),
+		Attempts:   report.Attempts,
+		Duration:   float64(report.Result.Duration) / 1e9,
+		Confidence: report.Result.Confidence,
+		Diff:       newResultDiff(report.Result).Compact(),
+	})
+}
+
+func (r *jsonReporter) Finish(summary TestSummary) error {
+	output := JSONOutput{
+		Summary: summary,
+		Tests:   r.results,
+	}
+	if output.Tests == nil {
+		output.Tests = []JSONTestResult{}
 	}
 
-	if r.format == OutputJSON {
-		output := JSONOutput{
-			Summary: r.summary,
-			Tests:   r.results,
-		}
+	encoder := json.NewEncoder(r.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// suggestReporter writes AI-oriented YAML (test code, hints, confidence notes) at the end
+type suggestReporter struct {
+	baseReporter
+	out     io.Writer
+	results []SuggestTestResult
+	flaky   []FlakyTest
+	budget  *suggestBudget // Compact output settings (nil = full output)
+}
 
-		encoder := json.NewEncoder(os.Stdout)
-		encoder.SetIndent("", "  ")
-		return encoder.Encode(output)
+func (r *suggestReporter) TestEnd(report TestReport) {
+	if report.Status == "not_run" || report.Test == nil {
+		return
 	}
 
-	if r.format == OutputSuggest {
-		message := "Vyb test results with AI-friendly context. "
-		if r.summary.Failed > 0 {
-			message += fmt.Sprintf("Found %d failing test(s). Review the hints for pattern-based suggestions. ", r.summary.Failed)
-			message += "For each failed test, check the test_code, failed_step, actual, expected, and hints fields. "
-			message += "The confidence_note provides guidance on whether the test or implementation is more likely to be wrong."
-		} else {
-			message += "All tests passed! "
-		}
+	r.results = append(r.results, newSuggestResult(report))
+	if report.Result.Flaky {
+		r.flaky = append(r.flaky, FlakyTest{Name: report.Result.Name, File: report.File, Attempts: report.Result.Attempts})
+	}
+}
 
-		output := SuggestOutput{
-			Summary: r.summary,
-			Tests:   r.suggestResults,
-			Message: message,
+func (r *suggestReporter) Finish(summary TestSummary) error {
+	message := "Vyb test results with AI-friendly context. "
+	if summary.Failed > 0 {
+		message += fmt.Sprintf("Found %d failing test(s). Review the hints for pattern-based suggestions. ", summary.Failed)
+		message += "For each failed test, check the test_code, failed_step, actual, expected, and hints fields. "
+		message += "The confidence_note provides guidance on whether the test or implementation is more likely to be wrong."
+		if summary.NotRun > 0 {
+			message += fmt.Sprintf(" The run stopped early: %d test(s) were not run.", summary.NotRun)
 		}
-
-		// Output as YAML for consistency with test file format and token efficiency
-		encoder := yaml.NewEncoder(os.Stdout)
-		encoder.SetIndent(2)
-		return encoder.Encode(output)
+	} else {
+		message += "All tests passed! "
 	}
-
-	// Pretty format summary
-	passColor := colorGreen
-	if r.summary.Failed > 0 {
-		passColor = colorRed
+	if gate := summary.ConfidenceGate; gate != nil && !gate.Passed {
+		message = strings.TrimSpace(message) + " " + gateMessage(gate)
+	}
+	if summary.Flaky > 0 {
+		message = strings.TrimSpace(message) + fmt.Sprintf(" %d test(s) are flaky (passed only after retrying) - they point at timing or randomness, not broken code; don't change the implementation to fix them.", summary.Flaky)
 	}
 
-	fmt.Printf("%sTests: %s%d passed%s, %s%d failed%s, %d total%s\n",
-		colorBold,
-		colorGreen, r.summary.Passed, colorReset+colorBold,
-		passColor, r.summary.Failed, colorReset+colorBold,
-		r.summary.Total, colorReset)
+	output := SuggestOutput{
+		Summary: summary,
+		Tests:   r.results,
+		Flaky:   r.flaky,
+		Message: message,
+	}
+	if output.Tests == nil {
+		output.Tests = []SuggestTestResult{}
+	}
 
-	// Confidence coverage
-	if r.summary.Total > 0 {
-		confidenceColor := colorGreen
-		if r.summary.AverageConfidence < 0.8 {
-			confidenceColor = colorYellow
+	if r.budget != nil {
+		// Keep the full results on disk so truncated values can be looked up
+		if err := writeSuggestOutput(r.budget.artifact, output); err != nil {
+			return err
 		}
-
-		fmt.Printf("%sConfidence: %savg %.2f%s, min %.2f, max %.2f%s\n",
-			colorBold,
-			confidenceColor, r.summary.AverageConfidence, colorReset+colorBold,
-			r.summary.MinConfidence, r.summary.MaxConfidence, colorReset)
+		output = compactSuggestOutput(output, r.budget)
 	}
 
-	fmt.Printf("Time: %.3fs\n", r.summary.Duration)
+	// Output as YAML for consistency with test file format and token efficiency
+	encoder := yaml.NewEncoder(r.out)
+	encoder.SetIndent(2)
+	return encoder.Encode(output)
+}
 
-	return nil
+// gateMessage is the guidance for a failed confidence gate
+func gateMessage(gate *ConfidenceGateReport) string {
+	return "The confidence gate failed (" + strings.Join(gate.Failures, "; ") + "): summary.confidence_gate.low_confidence lists the tests to review - raise their confidence only where the requirements are clear."
 }
 
-// Failed returns true if any tests failed
-func (r *Reporter) Failed() bool {
-	return r.summary.Failed > 0
+// newSuggestResult builds the AI-oriented view of a test result (test code, hints, confidence note)
+func newSuggestResult(report TestReport) SuggestTestResult {
+	result := report.Result
+	suggestResult := SuggestTestResult{
+		Name:           result.Name,
+		File:           report.File,
+		Status:         report.Status,
+		Error:          result.Error,
+		Code:           result.Code,
+		DidYouMean:     result.Suggestions,
+		Confidence:     result.Confidence,
+		Attempts:       report.Attempts,
+		TestCode:       formatTestCode(report.Test),
+		Hints:          report.Hints,
+		ConfidenceNote: report.Note,
+	}
+
+	if !result.Passed {
+		suggestResult.FailedStep = result.Step
+		suggestResult.StepIndex = failedStepIndex(result)
+		suggestResult.Actual = result.Actual
+		suggestResult.Expected = result.Expected
+		suggestResult.Diff = newResultDiff(result).Compact()
+		suggestResult.cause = result.Cause
+		suggestResult.operator = result.Operator
+	}
+
+	return suggestResult
 }
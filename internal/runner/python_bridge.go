@@ -13,6 +13,8 @@ import (
 
 // PythonBridge handles executing external Python functions
 type PythonBridge struct {
+	processTracker
+	functions  functionList
 	config     *parser.Config
 	bridgeCode string
 }
@@ -41,12 +43,19 @@ func (pb *PythonBridge) Call(functionName string, args []interface{}) (interface
 		args = []interface{}{}
 	}
 
-	// Create request payload
-	request := map[string]interface{}{
+	return pb.send(map[string]interface{}{
 		"function": functionName,
 		"args":     args,
-	}
+	})
+}
+
+// Functions lists the functions exported by the configured modules
+func (pb *PythonBridge) Functions() ([]string, error) {
+	return pb.functions.get(pb.send)
+}
 
+// send runs the bridge script with one request and returns its result
+func (pb *PythonBridge) send(request map[string]interface{}) (interface{}, error) {
 	requestJSON, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -62,31 +71,22 @@ func (pb *PythonBridge) Call(functionName string, args []interface{}) (interface
 
 	// Execute Python with the bridge script
 	cmd := exec.Command("python3", bridgeFile, string(requestJSON))
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+	output, err := pb.run(cmd)
+	response, ok := decodeBridgeResponse(output)
+	if !ok && err != nil {
 		// Try 'python' if 'python3' not found
 		cmd = exec.Command("python", bridgeFile, string(requestJSON))
-		output, err = cmd.CombinedOutput()
-		if err != nil {
-			return nil, fmt.Errorf("python execution failed: %w\nOutput: %s", err, string(output))
+		output, err = pb.run(cmd)
+		response, ok = decodeBridgeResponse(output)
+		if !ok && err != nil {
+			return nil, bridgeCrash(err, output, "python execution failed: %v\nOutput: %s", err, string(output))
 		}
 	}
-
-	// Parse response
-	var response struct {
-		Result interface{} `json:"result"`
-		Error  string      `json:"error"`
-	}
-
-	if err := json.Unmarshal(output, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse python response: %w\nOutput: %s", err, string(output))
-	}
-
-	if response.Error != "" {
-		return nil, fmt.Errorf("external function error: %s", response.Error)
+	if !ok {
+		return nil, bridgeCrash(nil, output, "failed to parse python response\nOutput: %s", string(output))
 	}
 
-	return response.Result, nil
+	return response.value()
 }
 
 // generatePythonBridgeScript creates the Python bridge script that imports modules and calls functions
@@ -116,6 +116,7 @@ func generatePythonBridgeScript(modules []string) (string, error) {
 
 import json
 import sys
+import traceback
 
 # Add module directories to Python path
 ` + strings.Join(sysPaths, "\n") + `
@@ -127,16 +128,26 @@ import sys
 functions = {}
 ` + strings.Join(functionMerges, "\n") + `
 
+class BridgeError(Exception):
+    def __init__(self, message, kind):
+        super().__init__(message)
+        self.kind = kind
+
 # Read request from command line argument
 request = json.loads(sys.argv[1])
 
+# List the exported functions (used for "did you mean" suggestions)
+if request.get('list'):
+    print(json.dumps({'result': sorted(functions.keys())}))
+    sys.exit(0)
+
 try:
     # Find and call the function
     fn = functions.get(request['function'])
 
     if fn is None:
         available = ', '.join(functions.keys())
-        raise Exception(f"Function not found: {request['function']}. Available: {available}")
+        raise BridgeError(f"Function not found: {request['function']}. Available: {available}", 'not_found')
 
     if not callable(fn):
         raise Exception(f"Not a function: {request['function']}")
@@ -145,11 +156,15 @@ try:
     result = fn(*request['args'])
 
     # Return result as JSON
-    print(json.dumps({'result': result}))
+    try:
+        response = json.dumps({'result': result})
+    except (TypeError, ValueError) as error:
+        raise BridgeError(str(error), 'unserializable')
+    print(response)
 
 except Exception as error:
     # Return error as JSON
-    print(json.dumps({'error': str(error)}))
+    print(json.dumps({'error': str(error), 'stack': traceback.format_exc(), 'kind': getattr(error, 'kind', None)}))
     sys.exit(1)
 `
 
@@ -0,0 +1,398 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// Reporter renders the events of a test run in one output format.
+// Reporters receive every event in order and write to their own destination.
+type Reporter interface {
+	RunStart(info RunInfo)
+	FileStart(file string)
+	TestStart(file string, test *parser.Test)
+	Step(file string, test *parser.Test, step StepResult)
+	TestEnd(report TestReport)
+	FileEnd(file string, summary FileSummary)
+	Notice(level NoticeLevel, message string)
+	Finish(summary TestSummary) error
+}
+
+// RunInfo describes a run that is about to start
+type RunInfo struct {
+	Files int
+	Seed  int64  // Shuffle seed (0 when not shuffled)
+	Shard string // Shard being run, e.g. "2/4" (empty when not sharded)
+}
+
+// TestReport is everything known about a finished (or never started) test
+type TestReport struct {
+	File     string
+	Status   string // "pass", "fail", "flaky" or "not_run"
+	Attempts int    // Number of attempts, set only when the test was retried
+	Result   parser.TestResult
+	Test     *parser.Test
+	Hints    []string // Suggestions for fixing a failed test, project hint rules first
+	Note     string   // Interpretation of the confidence, adjusted by recorded history
+	Reason   string   // Why a not_run test was never started, e.g. "failure limit reached"
+}
+
+// notRunMessage says why a not_run test was never started, e.g. "not run (stopped)"
+func notRunMessage(report TestReport) string {
+	if report.Reason == "" {
+		return "not run"
+	}
+	return "not run (" + report.Reason + ")"
+}
+
+// FileSummary summarizes the tests of a single file
+type FileSummary struct {
+	Total    int     `json:"total" yaml:"total"`
+	Passed   int     `json:"passed" yaml:"passed"`
+	Failed   int     `json:"failed" yaml:"failed"`
+	Flaky    int     `json:"flaky" yaml:"flaky"`
+	NotRun   int     `json:"not_run" yaml:"not_run"`
+	Duration float64 `json:"duration_seconds" yaml:"duration_seconds"`
+}
+
+// NoticeLevel classifies messages about the run itself (not about tests)
+type NoticeLevel int
+
+const (
+	NoticeInfo NoticeLevel = iota
+	NoticeWarning
+	NoticeError
+)
+
+// baseReporter implements every Reporter method as a no-op so that reporters
+// only need to implement the events they care about
+type baseReporter struct{}
+
+func (baseReporter) RunStart(info RunInfo)                                {}
+func (baseReporter) FileStart(file string)                                {}
+func (baseReporter) TestStart(file string, test *parser.Test)             {}
+func (baseReporter) Step(file string, test *parser.Test, step StepResult) {}
+func (baseReporter) TestEnd(report TestReport)                            {}
+func (baseReporter) FileEnd(file string, summary FileSummary)             {}
+func (baseReporter) Notice(level NoticeLevel, message string)             {}
+func (baseReporter) Finish(summary TestSummary) error                     { return nil }
+
+// NewReporter creates a reporter for the given format writing to out
+func NewReporter(format OutputFormat, out io.Writer) Reporter {
+	switch format {
+	case OutputPretty:
+		return &prettyReporter{out: out}
+	case OutputJSON:
+		return &jsonReporter{out: out}
+	case OutputJUnit:
+		return &junitReporter{out: out}
+	case OutputTAP:
+		return &tapReporter{out: out}
+	case OutputNDJSON:
+		return &ndjsonReporter{out: out}
+	case OutputGitHub:
+		return newGitHubReporter(out)
+	case OutputHTML:
+		return &htmlReporter{out: out}
+	case OutputSARIF:
+		return &sarifReporter{out: out}
+	default:
+		return &suggestReporter{out: out}
+	}
+}
+
+// ReporterSpec selects a reporter and its destination
+type ReporterSpec struct {
+	Format OutputFormat
+	Output string // File to write to (empty = stdout)
+}
+
+// ParseReporterSpec parses a --reporter value: "name" or "name=path"
+func ParseReporterSpec(value string) (ReporterSpec, error) {
+	name, output, _ := strings.Cut(value, "=")
+
+	format, err := ParseOutputFormat(strings.TrimSpace(name))
+	if err != nil {
+		return ReporterSpec{}, err
+	}
+
+	return ReporterSpec{Format: format, Output: strings.TrimSpace(output)}, nil
+}
+
+// multiReporter fans run events out to every configured reporter and keeps the
+// run and per-file summaries they all share
+type multiReporter struct {
+	reporters     []Reporter
+	closers       []io.Closer
+	summary       TestSummary
+	fileSummary   FileSummary  // Counts for the file currently running
+	confidenceSum float64      // For calculating average
+	hintRules     []hintRule   // Project hint rules from vyb.config.yaml and .vyb/hints.yaml
+	calibration   *calibration // Resolved failures from .vyb/history (nil = none)
+
+	gates       *confidenceGates    // Confidence gates (nil = none)
+	confidences []LowConfidenceTest // Confidence of every test that ran, for the gates
+}
+
+// newMultiReporter creates the reporters for opts.Reporters, opening their destination files
+func newMultiReporter(opts Options) (*multiReporter, error) {
+	specs := opts.Reporters
+	if len(specs) == 0 {
+		specs = []ReporterSpec{{Format: OutputSuggest}}
+	}
+
+	if err := checkReporterOutputs(specs); err != nil {
+		return nil, err
+	}
+
+	m := &multiReporter{
+		summary: TestSummary{
+			MinConfidence: 1.0, // Initialize to max, will be updated
+			MaxConfidence: 0.0, // Initialize to min, will be updated
+		},
+	}
+
+	for _, spec := range specs {
+		var out io.Writer = os.Stdout
+		if spec.Output != "" && spec.Output != "-" {
+			file, err := createOutputFile(spec.Output)
+			if err != nil {
+				m.Close()
+				return nil, err
+			}
+			m.closers = append(m.closers, file)
+			out = file
+		}
+
+		reporter := NewReporter(spec.Format, out)
+		if suggest, ok := reporter.(*suggestReporter); ok && (opts.Compact || opts.MaxTokens > 0) {
+			suggest.budget = &suggestBudget{maxTokens: opts.MaxTokens, artifact: suggestArtifactFile}
+		}
+		m.reporters = append(m.reporters, reporter)
+	}
+	m.reporters = append(m.reporters, opts.observers...)
+
+	return m, nil
+}
+
+// checkReporterOutputs rejects more than one reporter writing to stdout or to the same file
+func checkReporterOutputs(specs []ReporterSpec) error {
+	stdoutUsed := false
+	files := make(map[string]OutputFormat)
+	for _, spec := range specs {
+		if spec.Output != "" && spec.Output != "-" {
+			path, err := filepath.Abs(spec.Output)
+			if err != nil {
+				path = filepath.Clean(spec.Output)
+			}
+			if other, ok := files[path]; ok {
+				return fmt.Errorf("reporters %s and %s both write to %s", other, spec.Format, spec.Output)
+			}
+			files[path] = spec.Format
+			continue
+		}
+		if stdoutUsed {
+			return fmt.Errorf("only one reporter can write to stdout (use --reporter %s=<file>)", spec.Format)
+		}
+		stdoutUsed = true
+	}
+	return nil
+}
+
+// Close closes all report files and returns the first error
+func (m *multiReporter) Close() error {
+	var firstErr error
+	for _, closer := range m.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	m.closers = nil
+	return firstErr
+}
+
+// SetSeed records the shuffle seed so it appears in every output format
+func (m *multiReporter) SetSeed(seed int64) {
+	m.summary.Seed = seed
+}
+
+// SetShard records which shard of the test files this run covers
+func (m *multiReporter) SetShard(shard string) {
+	m.summary.Shard = shard
+}
+
+// SetHintRules sets the project hint rules applied to failed tests
+func (m *multiReporter) SetHintRules(rules []hintRule) {
+	m.hintRules = rules
+}
+
+// SetCalibration sets the recorded history used to adjust confidence notes
+func (m *multiReporter) SetCalibration(c *calibration) {
+	m.calibration = c
+}
+
+// SetConfidenceGates sets the confidence gates checked when the run finishes
+func (m *multiReporter) SetConfidenceGates(gates *confidenceGates) {
+	m.gates = gates
+}
+
+// Notice reports a message about the run itself (e.g. file selection, parse errors)
+func (m *multiReporter) Notice(level NoticeLevel, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	for _, reporter := range m.reporters {
+		reporter.Notice(level, message)
+	}
+}
+
+// RunStart reports that a run over the given number of files is starting
+func (m *multiReporter) RunStart(files int) {
+	info := RunInfo{Files: files, Seed: m.summary.Seed, Shard: m.summary.Shard}
+	for _, reporter := range m.reporters {
+		reporter.RunStart(info)
+	}
+}
+
+// FileStart reports that a test file is starting
+func (m *multiReporter) FileStart(file string) {
+	m.fileSummary = FileSummary{}
+	for _, reporter := range m.reporters {
+		reporter.FileStart(file)
+	}
+}
+
+// TestStart reports that a single test is about to run
+func (m *multiReporter) TestStart(file string, test *parser.Test) {
+	for _, reporter := range m.reporters {
+		reporter.TestStart(file, test)
+	}
+}
+
+// Step reports the outcome of a single when/then step
+func (m *multiReporter) Step(file string, test *parser.Test, step StepResult) {
+	for _, reporter := range m.reporters {
+		reporter.Step(file, test, step)
+	}
+}
+
+// TestEnd records a test result and reports it
+func (m *multiReporter) TestEnd(file string, result parser.TestResult, test *parser.Test) {
+	status := "fail"
+	if result.Flaky {
+		status = "flaky"
+	} else if result.Passed {
+		status = "pass"
+	}
+
+	attempts := 0
+	if result.Attempts > 1 {
+		attempts = result.Attempts
+	}
+
+	duration := float64(result.Duration) / 1e9
+
+	// Update summary
+	m.summary.Total++
+	m.fileSummary.Total++
+	if result.Passed {
+		m.summary.Passed++
+		m.fileSummary.Passed++
+		if result.Flaky {
+			m.summary.Flaky++
+			m.fileSummary.Flaky++
+		}
+	} else {
+		m.summary.Failed++
+		m.fileSummary.Failed++
+	}
+	m.summary.Duration += duration
+	m.fileSummary.Duration += duration
+
+	// Track confidence metrics
+	m.confidenceSum += result.Confidence
+	if m.gates != nil {
+		m.confidences = append(m.confidences, LowConfidenceTest{Name: result.Name, File: file, Line: test.Line, Confidence: result.Confidence})
+	}
+	if result.Confidence < m.summary.MinConfidence {
+		m.summary.MinConfidence = result.Confidence
+	}
+	if result.Confidence > m.summary.MaxConfidence {
+		m.summary.MaxConfidence = result.Confidence
+	}
+
+	report := TestReport{File: file, Status: status, Attempts: attempts, Result: result, Test: test}
+	report.Note = getConfidenceNote(result.Confidence, m.calibration.lookup(file, result.Confidence))
+	if !result.Passed {
+		report.Hints = mergeHints(projectHints(m.hintRules, file, result, test), generateHints(test, result))
+	}
+	for _, reporter := range m.reporters {
+		reporter.TestEnd(report)
+	}
+}
+
+// NotRun records a test that was never started because the run stopped early for reason
+func (m *multiReporter) NotRun(file string, test *parser.Test, reason string) {
+	m.summary.Total++
+	m.summary.NotRun++
+	m.fileSummary.Total++
+	m.fileSummary.NotRun++
+
+	report := TestReport{
+		File:   file,
+		Status: "not_run",
+		Reason: reason,
+		Result: parser.TestResult{Name: test.Name, Confidence: test.Confidence},
+		Test:   test,
+		Note:   getConfidenceNote(test.Confidence, m.calibration.lookup(file, test.Confidence)),
+	}
+	for _, reporter := range m.reporters {
+		reporter.TestEnd(report)
+	}
+}
+
+// FileEnd reports that a test file has finished
+func (m *multiReporter) FileEnd(file string) {
+	for _, reporter := range m.reporters {
+		reporter.FileEnd(file, m.fileSummary)
+	}
+}
+
+// Finish computes the final summary and lets every reporter write its output
+func (m *multiReporter) Finish() error {
+	// Calculate average confidence (over tests that actually ran)
+	if executed := m.summary.Passed + m.summary.Failed; executed > 0 {
+		m.summary.AverageConfidence = m.confidenceSum / float64(executed)
+	} else {
+		// No tests run - set to 0
+		m.summary.MinConfidence = 0.0
+		m.summary.MaxConfidence = 0.0
+	}
+	if m.gates != nil {
+		m.summary.ConfidenceGate = m.gates.check(m.summary, m.confidences)
+	}
+
+	var firstErr error
+	for _, reporter := range m.reporters {
+		if err := reporter.Finish(m.summary); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Failed returns true if any tests failed
+func (m *multiReporter) Failed() bool {
+	return m.summary.Failed > 0
+}
+
+// GateFailures returns the confidence gates the run did not meet (after Finish)
+func (m *multiReporter) GateFailures() []string {
+	if m.summary.ConfidenceGate == nil {
+		return nil
+	}
+	return m.summary.ConfidenceGate.Failures
+}
@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestParseReporterSpec(t *testing.T) {
+	tests := []struct {
+		value  string
+		format OutputFormat
+		output string
+	}{
+		{"pretty", OutputPretty, ""},
+		{"junit=out/junit.xml", OutputJUnit, "out/junit.xml"},
+		{"suggest=vyb.yaml", OutputSuggest, "vyb.yaml"},
+	}
+
+	for _, tt := range tests {
+		spec, err := ParseReporterSpec(tt.value)
+		if err != nil {
+			t.Fatalf("ParseReporterSpec(%q) error = %v", tt.value, err)
+		}
+		if spec.Format != tt.format || spec.Output != tt.output {
+			t.Errorf("ParseReporterSpec(%q) = %+v, want %s=%s", tt.value, spec, tt.format, tt.output)
+		}
+	}
+
+	if _, err := ParseReporterSpec("html5=report.html"); err == nil {
+		t.Error("ParseReporterSpec should reject unknown reporters")
+	}
+}
+
+func TestMultiReporterRejectsSharedStdout(t *testing.T) {
+	_, err := newMultiReporter(Options{Reporters: []ReporterSpec{{Format: OutputPretty}, {Format: OutputTAP}}})
+	if err == nil {
+		t.Fatal("expected an error when two reporters write to stdout")
+	}
+
+	// Run checks before looking for test files, so the mistake is reported even without any
+	opts := Options{Reporters: []ReporterSpec{{Format: OutputJSON}, {Format: OutputTAP, Output: "-"}}}
+	if err := Run(filepath.Join(t.TempDir(), "*.vyb"), opts); err == nil || !strings.Contains(err.Error(), "stdout") {
+		t.Errorf("Run() error = %v, want the stdout error", err)
+	}
+}
+
+func TestMultiReporterRejectsSharedFile(t *testing.T) {
+	specs := []ReporterSpec{{Format: OutputJUnit, Output: "out/report.xml"}, {Format: OutputTAP, Output: "./out/report.xml"}}
+	if err := checkReporterOutputs(specs); err == nil || !strings.Contains(err.Error(), "both write to") {
+		t.Errorf("checkReporterOutputs() error = %v, want the shared file error", err)
+	}
+}
+
+func TestMultiReporterFansOutEvents(t *testing.T) {
+	var tap, json bytes.Buffer
+	reporter := &multiReporter{
+		reporters: []Reporter{NewReporter(OutputTAP, &tap), NewReporter(OutputJSON, &json)},
+		summary:   TestSummary{MinConfidence: 1.0},
+	}
+
+	test := &parser.Test{Name: "adds", Confidence: 0.9}
+	reporter.RunStart(1)
+	reporter.FileStart("math.vyb")
+	reporter.TestEnd("math.vyb", parser.TestResult{Name: "adds", Passed: true, Confidence: 0.9}, test)
+	reporter.NotRun("math.vyb", &parser.Test{Name: "subtracts", Confidence: 0.8}, "failure limit reached")
+	reporter.FileEnd("math.vyb")
+	if err := reporter.Finish(); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	if !strings.Contains(tap.String(), "ok 1 - adds") || !strings.Contains(tap.String(), "1..2") {
+		t.Errorf("TAP output missing test points:\n%s", tap.String())
+	}
+	if !strings.Contains(json.String(), `"not_run": 1`) || !strings.Contains(json.String(), `"name": "subtracts"`) {
+		t.Errorf("JSON output missing results:\n%s", json.String())
+	}
+	if reporter.Failed() {
+		t.Error("Failed() = true, want false")
+	}
+}
@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,17 +11,95 @@ import (
 	"github.com/vybtest/vyb/internal/parser"
 )
 
+// Version is the Vyb release reported in output headers and event streams
+// (set by the CLI from its build version)
+var Version = "0.1.0-alpha"
+
+// Options controls how tests are selected, executed and reported
+type Options struct {
+	Reporters  []ReporterSpec // Reporters to run (default: suggest to stdout)
+	Watch      bool
+	Changed    bool   // Only run test files affected by changes in the git working tree
+	ChangedRef string // Git ref to diff against when Changed is set (default: HEAD)
+
+	MaxFailures int // Stop scheduling tests after this many failures (0 = no limit)
+
+	Retries int // Extra attempts for failing tests (a test's own retries: field takes precedence)
+
+	ShardIndex int    // 1-based shard to run when ShardTotal > 0
+	ShardTotal int    // Number of shards the test files are split into (0 = no sharding)
+	Durations  string // Shared durations file to balance shards by and record into (empty = config's, if any)
+
+	Shuffle bool  // Randomize the order of files and of tests within files
+	Seed    int64 // Seed for Shuffle (0 = pick a new one)
+
+	Compact   bool // Trim suggest output to failures only, deduplicated and truncated
+	MaxTokens int  // Approximate token budget for suggest output (implies Compact, 0 = no limit)
+
+	MinAvgConfidence  *float64                // Fail the run when average confidence is lower (nil = use config, 0 = no minimum)
+	RequireConfidence []ConfidenceRequirement // Minimum confidence for tests in matching files (added to config)
+
+	// Set by watch mode
+	testFilter func(file string, test *parser.Test) bool // Tests to run (nil = all)
+	observers  []Reporter                                // Extra reporters receiving the run's events
+	stop       <-chan struct{}                           // Closed to stop after the current test (nil = never)
+}
+
 // Run executes tests matching the pattern
-func Run(pattern string, watch bool, format OutputFormat) error {
-	if watch {
-		return Watch(pattern, format)
+func Run(pattern string, opts Options) error {
+	if err := checkReporterOutputs(opts.Reporters); err != nil {
+		return err
 	}
 
-	return runOnce(pattern, format)
+	if opts.Watch {
+		return Watch(pattern, opts)
+	}
+
+	return runOnce(pattern, opts)
+}
+
+// TestsFailedError is returned by a run in which tests failed. The reporters have
+// already shown the failures.
+type TestsFailedError struct {
+	Failed int
+}
+
+func (e *TestsFailedError) Error() string {
+	return fmt.Sprintf("%d test(s) failed", e.Failed)
 }
 
-// runOnce runs tests once (used by both Run and watch mode)
-func runOnce(pattern string, format OutputFormat) error {
+// Pretty reports whether human-readable output goes to the terminal, so status
+// messages outside of test results can be printed
+func (opts Options) Pretty() bool {
+	for _, spec := range opts.Reporters {
+		if spec.Format == OutputPretty && (spec.Output == "" || spec.Output == "-") {
+			return true
+		}
+	}
+	return false
+}
+
+// runOnce runs the tests matching pattern once
+func runOnce(pattern string, opts Options) error {
+	// Find test files based on pattern
+	files, err := findTestFiles(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to find test files: %w", err)
+	}
+
+	if len(files) == 0 {
+		if opts.Pretty() {
+			fmt.Println("No test files found matching pattern:", pattern)
+		}
+		return nil
+	}
+
+	return runFiles(files, opts)
+}
+
+// runFiles runs the given test files once (used by runOnce and by watch mode to re-run
+// the affected files)
+func runFiles(files []string, opts Options) error {
 	// Load configuration (if exists)
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -32,88 +111,370 @@ func runOnce(pattern string, format OutputFormat) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Find test files based on pattern
-	files, err := findTestFiles(pattern)
+	hintRules, err := loadHintRules(cwd, config)
 	if err != nil {
-		return fmt.Errorf("failed to find test files: %w", err)
+		return err
 	}
 
-	if len(files) == 0 {
-		if format == OutputPretty {
-			fmt.Println("No test files found matching pattern:", pattern)
+	// History only refines confidence notes, so a bad history file never stops a run
+	calibration, skippedHistory, historyErr := loadCalibration(cwd)
+
+	durationsFile := durationsPath(cwd, config, opts.Durations)
+
+	gates, err := newConfidenceGates(config, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Changed {
+		files, err = filterChangedFiles(files, config, cwd, opts)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			if opts.Pretty() {
+				fmt.Println("No test files affected by changes")
+			}
+			return nil
 		}
-		return nil
 	}
 
-	reporter := NewReporter(format)
+	if opts.ShardTotal > 0 {
+		all := len(files)
+		files = shardFiles(files, opts.ShardIndex, opts.ShardTotal, loadDurations(durationsFile))
+		if opts.Pretty() {
+			fmt.Printf("%sShard %d/%d: %d of %d test file(s)%s\n", colorGray, opts.ShardIndex, opts.ShardTotal, len(files), all, colorReset)
+		}
+		if len(files) == 0 {
+			return nil
+		}
+	}
+
+	if opts.Shuffle {
+		if opts.Seed == 0 {
+			opts.Seed = newSeed()
+		}
+		files = shuffleFiles(files, opts.Seed)
+	}
+
+	reporter, err := newMultiReporter(opts)
+	if err != nil {
+		return err
+	}
+	defer reporter.Close()
 
-	// Pretty header
-	if format == OutputPretty {
-		fmt.Printf("\n🌊 Vyb v0.1.0-alpha\n\n")
+	if opts.Shuffle {
+		reporter.SetSeed(opts.Seed)
+	}
+	if opts.ShardTotal > 0 {
+		reporter.SetShard(fmt.Sprintf("%d/%d", opts.ShardIndex, opts.ShardTotal))
+	}
+	reporter.SetHintRules(hintRules)
+	reporter.SetCalibration(calibration)
+	if historyErr != nil {
+		reporter.Notice(NoticeWarning, "Warning: failed to read history, confidence notes are not calibrated: %v", historyErr)
+	} else if skippedHistory > 0 {
+		reporter.Notice(NoticeWarning, "Warning: skipped %d malformed line(s) in %s", skippedHistory, historyFile)
+	}
+	reporter.SetConfidenceGates(gates)
+	reporter.RunStart(len(files))
+
+	durations := make(map[string]float64)
+	var history []historyEntry
+	runTime := time.Now().UTC()
+	stopReason := "" // Why remaining tests are not run (empty while running)
+	checkStop := func() {
+		if stopReason == "" && stopRequested(opts.stop) {
+			stopReason = "stopped"
+			reporter.Notice(NoticeWarning, "  ⛔ Stopped, remaining tests are not run")
+		}
 	}
 
+	// Stop scheduling new tests once too many failed; remaining ones are reported as not run
+	checkMaxFailures := func() {
+		if stopReason == "" && opts.MaxFailures > 0 && reporter.summary.Failed >= opts.MaxFailures {
+			stopReason = "failure limit reached"
+			reporter.Notice(NoticeWarning, "  ⛔ Stopping after %d failure(s)", reporter.summary.Failed)
+		}
+	}
 	for _, file := range files {
-		// Detect runtime from each file
-		runtime := detectRuntime(file)
-
-		// Create language bridge if config specifies modules
-		var bridge Bridge
-		if config != nil && len(config.Modules) > 0 {
-			switch runtime {
-			case "node":
-				bridge, err = NewNodeBridge(config)
-				if err != nil {
-					return fmt.Errorf("failed to create node bridge: %w", err)
-				}
-			case "python":
-				bridge, err = NewPythonBridge(config)
-				if err != nil {
-					return fmt.Errorf("failed to create python bridge: %w", err)
-				}
-			case "lua":
-				bridge, err = NewLuaBridge(config)
-				if err != nil {
-					return fmt.Errorf("failed to create lua bridge: %w", err)
-				}
-			default:
-				return fmt.Errorf("unsupported runtime: %s for file %s", runtime, file)
-			}
+		checkStop()
+		if stopReason != "" {
+			reportNotRunFile(reporter, file, opts, stopReason)
+			continue
 		}
+		fileStart := time.Now()
 
-		testFile, err := parser.Parse(file)
+		testFile, err := loadTestFile(file, opts)
 		if err != nil {
-			if format == OutputPretty {
-				fmt.Printf("❌ Failed to parse %s: %v\n", file, err)
-			}
+			reporter.Notice(NoticeError, "Failed to parse %s: %v", file, err)
 			continue
 		}
+		if opts.testFilter != nil && len(testFile.Tests) == 0 {
+			continue // No test in this file passes the filter
+		}
+
+		bridge, err := newBridge(config, file)
+		if err != nil {
+			reporter.Notice(NoticeError, "Failed to run %s: %v", file, err)
+			reportErroredFile(reporter, file, testFile, err)
+			checkMaxFailures()
+			continue
+		}
+
+		reporter.FileStart(file)
+
+		for i := range testFile.Tests {
+			test := &testFile.Tests[i]
+			checkStop()
+			if stopReason != "" {
+				reporter.NotRun(file, test, stopReason)
+				continue
+			}
+
+			reporter.TestStart(file, test)
+			observe := func(step StepResult) {
+				reporter.Step(file, test, step)
+			}
+			result := runTestWithRetries(test, bridge, opts.Retries, observe)
+			reporter.TestEnd(file, result, test)
+			if !result.Passed {
+				history = append(history, newFailureEntry(runTime, file, test, result))
+			}
+
+			checkMaxFailures()
+		}
 
-		reporter.ReportTestStart(file)
+		closeBridge(bridge)
+		reporter.FileEnd(file)
 
-		for _, test := range testFile.Tests {
-			result := runTest(&test, bridge)
-			reporter.ReportTestResultWithTest(file, result, &test)
+		if stopReason == "" && opts.testFilter == nil {
+			durations[file] = time.Since(fileStart).Seconds() // Only whole files balance shards
 		}
+	}
 
-		reporter.ReportFileEnd()
+	// Record durations so future --shard runs can balance by time
+	if durationsFile != "" {
+		if err := saveDurations(durationsFile, durations); err != nil {
+			reporter.Notice(NoticeWarning, "Warning: failed to record durations: %v", err)
+		}
+	}
+
+	// Record failures so they can be resolved and checked against their confidence.
+	// Watch re-runs would record every save while a test is being fixed, so they don't.
+	if !opts.Watch {
+		if err := appendHistory(cwd, history); err != nil {
+			reporter.Notice(NoticeWarning, "Warning: failed to record history: %v", err)
+		}
 	}
 
 	// Output final summary
-	if err := reporter.Finalize(); err != nil {
+	if err := reporter.Finish(); err != nil {
 		return err
 	}
+	if err := reporter.Close(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
 
 	if reporter.Failed() {
-		return fmt.Errorf("%d test(s) failed", reporter.summary.Failed)
+		return &TestsFailedError{Failed: reporter.summary.Failed}
+	}
+	if failures := reporter.GateFailures(); len(failures) > 0 {
+		return &ConfidenceGateError{Failures: failures}
 	}
 
 	return nil
 }
 
+// createOutputFile creates (or truncates) a report file, creating parent directories
+func createOutputFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return file, nil
+}
+
+// loadTestFile parses a test file, keeping the tests that pass the filter and applying
+// the --shuffle order when enabled
+func loadTestFile(file string, opts Options) (*parser.TestFile, error) {
+	testFile, err := parser.Parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.testFilter != nil {
+		tests := testFile.Tests[:0]
+		for i := range testFile.Tests {
+			if opts.testFilter(file, &testFile.Tests[i]) {
+				tests = append(tests, testFile.Tests[i])
+			}
+		}
+		testFile.Tests = tests
+	}
+
+	if opts.Shuffle {
+		shuffleTests(file, testFile.Tests, opts.Seed)
+	}
+
+	return testFile, nil
+}
+
+// stopRequested reports whether stop has been closed
+func stopRequested(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// reportNotRunFile marks every test in a file that was never started as not run for
+// reason, bracketed by FileStart/FileEnd like a file that ran
+func reportNotRunFile(reporter *multiReporter, file string, opts Options, reason string) {
+	testFile, err := loadTestFile(file, opts)
+	if err != nil || len(testFile.Tests) == 0 {
+		return
+	}
+
+	reporter.FileStart(file)
+	for i := range testFile.Tests {
+		reporter.NotRun(file, &testFile.Tests[i], reason)
+	}
+	reporter.FileEnd(file)
+}
+
+// newBridge starts the language bridge for a test file's runtime (nil when no modules
+// are configured)
+func newBridge(config *parser.Config, file string) (Bridge, error) {
+	if config == nil || len(config.Modules) == 0 {
+		return nil, nil
+	}
+
+	switch runtime := detectRuntime(file); runtime {
+	case "node":
+		bridge, err := NewNodeBridge(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create node bridge: %w", err)
+		}
+		return bridge, nil
+	case "python":
+		bridge, err := NewPythonBridge(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create python bridge: %w", err)
+		}
+		return bridge, nil
+	case "lua":
+		bridge, err := NewLuaBridge(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lua bridge: %w", err)
+		}
+		return bridge, nil
+	default:
+		return nil, fmt.Errorf("unsupported runtime: %s for file %s", runtime, file)
+	}
+}
+
+// reportErroredFile reports every test of a file that could not be run as failed
+// with err, so the run still fails and report files stay complete
+func reportErroredFile(reporter *multiReporter, file string, testFile *parser.TestFile, err error) {
+	reporter.FileStart(file)
+	for i := range testFile.Tests {
+		test := &testFile.Tests[i]
+		result := parser.TestResult{Name: test.Name, Error: err.Error(), Cause: err.Error(), Code: string(errorCode(err)), Confidence: test.Confidence}
+		reporter.TestEnd(file, result, test)
+	}
+	reporter.FileEnd(file)
+}
+
+// filterChangedFiles keeps only the test files affected by changes since opts.ChangedRef
+func filterChangedFiles(files []string, config *parser.Config, projectDir string, opts Options) ([]string, error) {
+	changed, err := gitChangedFiles(opts.ChangedRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect changed files: %w", err)
+	}
+
+	selected, fallback := selectAffectedFiles(files, changed, config, projectDir)
+	if opts.Pretty() {
+		if fallback != "" {
+			fmt.Printf("%sRunning all tests: %s%s\n", colorYellow, fallback, colorReset)
+		} else {
+			fmt.Printf("%sRunning %d of %d test file(s) affected by changes%s\n", colorGray, len(selected), len(files), colorReset)
+		}
+	}
+
+	return selected, nil
+}
+
+// StepResult describes the outcome of a single when/then step
+type StepResult struct {
+	Phase    string // "when" or "then"
+	Index    int    // 0-based position within the phase
+	Text     string
+	Passed   bool
+	Error    string
+	Duration int64 // nanoseconds
+	Attempt  int   // Attempt number when the test is retried
+}
+
+// StepObserver is notified after every executed step (may be nil)
+type StepObserver func(step StepResult)
+
+// runTestWithRetries runs a test until it passes or its retries are exhausted.
+// A test that passes only after retrying is marked as flaky.
+func runTestWithRetries(test *parser.Test, bridge Bridge, defaultRetries int, observe StepObserver) parser.TestResult {
+	retries := defaultRetries
+	if test.Retries != nil {
+		retries = *test.Retries // Set in the file, even to 0 to opt out of --retries
+	}
+
+	var result parser.TestResult
+	var elapsed int64
+	for attempt := 1; attempt <= retries+1; attempt++ {
+		var attemptObserver StepObserver
+		if observe != nil {
+			attempt := attempt
+			attemptObserver = func(step StepResult) {
+				step.Attempt = attempt
+				observe(step)
+			}
+		}
+
+		result = runTest(test, bridge, attemptObserver)
+		elapsed += result.Duration
+		result.Attempts = attempt
+		if result.Passed {
+			result.Flaky = attempt > 1
+			break
+		}
+	}
+
+	result.Duration = elapsed
+	return result
+}
+
 // runTest executes a single test
-func runTest(test *parser.Test, bridge Bridge) parser.TestResult {
+func runTest(test *parser.Test, bridge Bridge, observe StepObserver) parser.TestResult {
 	start := time.Now()
 
+	notify := func(phase string, index int, text string, stepStart time.Time, err string) {
+		if observe != nil {
+			observe(StepResult{
+				Phase:    phase,
+				Index:    index,
+				Text:     text,
+				Passed:   err == "",
+				Error:    err,
+				Duration: time.Since(stepStart).Nanoseconds(),
+			})
+		}
+	}
+
 	// Create context with or without bridge
 	var ctx *Context
 	if bridge != nil {
@@ -128,39 +489,66 @@ func runTest(test *parser.Test, bridge Bridge) parser.TestResult {
 	}
 
 	// Execute "when" block (run statements)
-	for _, stmt := range test.When {
+	for i, stmt := range test.When {
+		stepStart := time.Now()
 		if err := executeStatement(ctx, stmt); err != nil {
+			notify("when", i, stmt, stepStart, err.Error())
 			return parser.TestResult{
-				Name:     test.Name,
-				Passed:   false,
-				Error:    fmt.Sprintf("Failed to execute statement '%s': %v", stmt, err),
-				Duration: time.Since(start).Nanoseconds(),
+				Name:        test.Name,
+				Passed:      false,
+				Error:       fmt.Sprintf("Failed to execute statement '%s': %v", stmt, err),
+				Cause:       errorCause(err),
+				Code:        string(errorCode(err)),
+				Suggestions: errorSuggestions(err),
+				Step:        "when",
+				StepIndex:   i,
+				Duration:    time.Since(start).Nanoseconds(),
+				Confidence:  test.Confidence,
+				Stack:       bridgeStack(err),
+				Function:    failedFunction(err),
 			}
 		}
+		notify("when", i, stmt, stepStart, "")
 	}
 
 	// Execute "then" block (check expectations)
-	for _, expectation := range test.Then {
+	for i, expectation := range test.Then {
+		stepStart := time.Now()
 		result := ctx.CheckExpectation(expectation)
 		if result.Error != nil {
+			notify("then", i, expectation, stepStart, result.Error.Error())
 			return parser.TestResult{
-				Name:     test.Name,
-				Passed:   false,
-				Error:    fmt.Sprintf("Failed to check expectation '%s': %v", expectation, result.Error),
-				Duration: time.Since(start).Nanoseconds(),
+				Name:        test.Name,
+				Passed:      false,
+				Error:       fmt.Sprintf("Failed to check expectation '%s': %v", expectation, result.Error),
+				Cause:       errorCause(result.Error),
+				Code:        string(errorCode(result.Error)),
+				Suggestions: errorSuggestions(result.Error),
+				Step:        "then",
+				StepIndex:   i,
+				Duration:    time.Since(start).Nanoseconds(),
+				Confidence:  test.Confidence,
+				Stack:       bridgeStack(result.Error),
+				Function:    failedFunction(result.Error),
 			}
 		}
 		if !result.Passed {
+			notify("then", i, expectation, stepStart, "expectation not met")
 			return parser.TestResult{
 				Name:       test.Name,
 				Passed:     false,
 				Error:      fmt.Sprintf("Expectation failed: %s", expectation),
+				Code:       string(CodeAssertMismatch),
+				Step:       "then",
+				StepIndex:  i,
 				Duration:   time.Since(start).Nanoseconds(),
 				Confidence: test.Confidence,
 				Actual:     result.Actual,
 				Expected:   result.Expected,
+				Operator:   result.Operator,
 			}
 		}
+		notify("then", i, expectation, stepStart, "")
 	}
 
 	return parser.TestResult{
@@ -171,12 +559,44 @@ func runTest(test *parser.Test, bridge Bridge) parser.TestResult {
 	}
 }
 
+// bridgeStack returns the stack trace of an error thrown by an external function
+func bridgeStack(err error) string {
+	var bridgeErr *BridgeError
+	if errors.As(err, &bridgeErr) {
+		return bridgeErr.Stack
+	}
+	return ""
+}
+
+// errorCause returns the message of the external call or bridge error behind err, or
+// err's own message
+func errorCause(err error) string {
+	var callErr *callError
+	if errors.As(err, &callErr) {
+		return callErr.Error()
+	}
+	var bridgeErr *BridgeError
+	if errors.As(err, &bridgeErr) {
+		return bridgeErr.Message
+	}
+	return err.Error()
+}
+
+// failedFunction returns the external function whose call caused err, if any
+func failedFunction(err error) string {
+	var callErr *callError
+	if errors.As(err, &callErr) {
+		return callErr.Function
+	}
+	return ""
+}
+
 // executeStatement executes a "when" statement (variable = expression)
 func executeStatement(ctx *Context, stmt string) error {
 	// Parse: variable = expression
 	parts := strings.Split(stmt, "=")
 	if len(parts) != 2 {
-		return fmt.Errorf("invalid statement format: %s (expected: var = expr)", stmt)
+		return newError(CodeInvalidStatement, "invalid statement format: %s (expected: var = expr)", stmt)
 	}
 
 	target := strings.TrimSpace(parts[0])
@@ -197,7 +617,7 @@ func executeStatement(ctx *Context, stmt string) error {
 		// Get the object
 		obj, ok := ctx.Get(objName)
 		if !ok {
-			return fmt.Errorf("undefined variable: %s", objName)
+			return ctx.undefinedVariable(objName)
 		}
 
 		// Set the property
@@ -259,7 +679,7 @@ func findTestFiles(pattern string) ([]string, error) {
 				continue
 			}
 			for _, match := range matches {
-				if !fileSet[match] {
+				if !fileSet[match] && !isDir(match) {
 					fileSet[match] = true
 					files = append(files, match)
 				}
@@ -275,7 +695,7 @@ func findTestFiles(pattern string) ([]string, error) {
 			return nil, err
 		}
 		for _, match := range matches {
-			if !fileSet[match] {
+			if !fileSet[match] && !isDir(match) {
 				fileSet[match] = true
 				files = append(files, match)
 			}
@@ -299,7 +719,7 @@ func findTestFiles(pattern string) ([]string, error) {
 			continue
 		}
 		for _, match := range matches {
-			if !fileSet[match] {
+			if !fileSet[match] && !isDir(match) {
 				fileSet[match] = true
 				files = append(files, match)
 			}
@@ -308,3 +728,10 @@ func findTestFiles(pattern string) ([]string, error) {
 
 	return files, nil
 }
+
+// isDir reports whether path is a directory (e.g. the .vyb state directory, which
+// matches *.vyb patterns)
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,334 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// countingBridge fails the first failures calls and then returns value
+type countingBridge struct {
+	failures int
+	calls    int
+	value    interface{}
+}
+
+func (b *countingBridge) Call(functionName string, args []interface{}) (interface{}, error) {
+	b.calls++
+	if b.calls <= b.failures {
+		return nil, fmt.Errorf("timed out")
+	}
+	return b.value, nil
+}
+
+func retryTest(retries *int) *parser.Test {
+	return &parser.Test{
+		Name:       "eventually consistent",
+		Confidence: 0.9,
+		Retries:    retries,
+		When:       []string{"value = fetchValue()"},
+		Then:       []string{"expect: value == 42"},
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestRunTestWithRetriesMarksFlaky(t *testing.T) {
+	bridge := &countingBridge{failures: 2, value: 42.0}
+
+	result := runTestWithRetries(retryTest(intPtr(3)), bridge, 0, nil)
+	if !result.Passed {
+		t.Fatalf("Expected test to pass after retrying, got error: %s", result.Error)
+	}
+	if !result.Flaky {
+		t.Error("Expected test to be marked flaky")
+	}
+	if result.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", result.Attempts)
+	}
+}
+
+func TestRunTestWithRetriesExhausted(t *testing.T) {
+	bridge := &countingBridge{failures: 5, value: 42.0}
+
+	result := runTestWithRetries(retryTest(nil), bridge, 2, nil)
+	if result.Passed || result.Flaky {
+		t.Error("Expected test to fail after exhausting retries")
+	}
+	if result.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", result.Attempts)
+	}
+}
+
+func TestRunTestWithRetriesOptOut(t *testing.T) {
+	bridge := &countingBridge{failures: 5, value: 42.0}
+
+	// retries: 0 in the file overrides --retries
+	result := runTestWithRetries(retryTest(intPtr(0)), bridge, 2, nil)
+	if result.Passed || result.Attempts != 1 {
+		t.Errorf("Expected a single failed attempt, got %+v", result)
+	}
+}
+
+func TestRunTestWithRetriesPassesFirstTime(t *testing.T) {
+	bridge := &countingBridge{value: 42.0}
+
+	result := runTestWithRetries(retryTest(intPtr(3)), bridge, 0, nil)
+	if !result.Passed || result.Flaky || result.Attempts != 1 {
+		t.Errorf("Expected clean pass on first attempt, got %+v", result)
+	}
+}
+
+// eventRecorder records the file and test events it receives, one line per event
+type eventRecorder struct {
+	baseReporter
+	events []string
+}
+
+func (r *eventRecorder) FileStart(file string) {
+	r.events = append(r.events, "start "+file)
+}
+
+func (r *eventRecorder) TestEnd(report TestReport) {
+	r.events = append(r.events, report.Status+" "+report.Result.Name)
+}
+
+func (r *eventRecorder) FileEnd(file string, summary FileSummary) {
+	r.events = append(r.events, fmt.Sprintf("end %s (%d failed, %d not run)", file, summary.Failed, summary.NotRun))
+}
+
+func (r *eventRecorder) Finish(summary TestSummary) error {
+	r.events = append(r.events, fmt.Sprintf("finish (%d total, %d failed, %d not run)", summary.Total, summary.Failed, summary.NotRun))
+	return nil
+}
+
+func TestRunFilesStopsAfterMaxFailures(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.WriteFile("a.vyb", []byte(`breaks:
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 3"
+adds:
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 2"
+also breaks:
+  when:
+    - x = add(2, 2)
+  then:
+    - "expect: x == 5"
+`), 0644)
+	os.WriteFile("b.vyb", []byte(`subtracts:
+  when:
+    - x = add(2, -1)
+  then:
+    - "expect: x == 1"
+`), 0644)
+
+	tests := []struct {
+		name        string
+		maxFailures int
+		want        []string
+	}{
+		{
+			name: "no limit",
+			want: []string{
+				"start a.vyb", "fail breaks", "pass adds", "fail also breaks", "end a.vyb (2 failed, 0 not run)",
+				"start b.vyb", "pass subtracts", "end b.vyb (0 failed, 0 not run)",
+				"finish (4 total, 2 failed, 0 not run)",
+			},
+		},
+		{
+			name:        "bail",
+			maxFailures: 1,
+			want: []string{
+				"start a.vyb", "fail breaks", "not_run adds", "not_run also breaks", "end a.vyb (1 failed, 2 not run)",
+				"start b.vyb", "not_run subtracts", "end b.vyb (0 failed, 1 not run)",
+				"finish (4 total, 1 failed, 3 not run)",
+			},
+		},
+		{
+			name:        "max failures",
+			maxFailures: 2,
+			want: []string{
+				"start a.vyb", "fail breaks", "pass adds", "fail also breaks", "end a.vyb (2 failed, 0 not run)",
+				"start b.vyb", "not_run subtracts", "end b.vyb (0 failed, 1 not run)",
+				"finish (4 total, 2 failed, 1 not run)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &eventRecorder{}
+			opts := Options{
+				Reporters:   []ReporterSpec{{Format: OutputJSON, Output: filepath.Join("out", "results.json")}},
+				MaxFailures: tt.maxFailures,
+				observers:   []Reporter{recorder},
+			}
+
+			err := runFiles([]string{"a.vyb", "b.vyb"}, opts)
+			if err == nil || !strings.Contains(err.Error(), "test(s) failed") {
+				t.Errorf("runFiles() error = %v, want failed tests", err)
+			}
+			if !reflect.DeepEqual(recorder.events, tt.want) {
+				t.Errorf("events:\n%s\nwant:\n%s", strings.Join(recorder.events, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRunFilesReportsWhyTestsAreNotRun(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.WriteFile("a.vyb", []byte(`breaks:
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 3"
+adds:
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 2"
+`), 0644)
+
+	stopped := make(chan struct{})
+	close(stopped)
+	for _, tt := range []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"max failures", Options{MaxFailures: 1}, "failure limit reached"},
+		{"stopped", Options{stop: stopped}, "stopped"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &reportCollector{}
+			tt.opts.Reporters = []ReporterSpec{{Format: OutputJSON, Output: filepath.Join("out", "results.json")}}
+			tt.opts.observers = []Reporter{collector}
+			runFiles([]string{"a.vyb"}, tt.opts)
+
+			last := collector.reports[len(collector.reports)-1]
+			if last.Status != "not_run" || last.Reason != tt.want {
+				t.Errorf("last report = %s (%q), want not_run (%q)", last.Status, last.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunFilesReportsFilesThatCannotRun(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	// There is no Go bridge, so the file's tests can't run
+	os.WriteFile("vyb.config.yaml", []byte("modules:\n  - ./calc.go\n"), 0644)
+	os.WriteFile("calc.go.vyb", []byte(`adds:
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 2"
+subtracts:
+  when:
+    - x = subtract(2, 1)
+  then:
+    - "expect: x == 1"
+`), 0644)
+
+	recorder := &eventRecorder{}
+	output := filepath.Join("out", "results.json")
+	opts := Options{Reporters: []ReporterSpec{{Format: OutputJSON, Output: output}}, observers: []Reporter{recorder}}
+	if err := runFiles([]string{"calc.go.vyb"}, opts); err == nil || !strings.Contains(err.Error(), "test(s) failed") {
+		t.Errorf("runFiles() error = %v, want failed tests", err)
+	}
+
+	want := []string{"start calc.go.vyb", "fail adds", "fail subtracts", "end calc.go.vyb (2 failed, 0 not run)", "finish (2 total, 2 failed, 0 not run)"}
+	if !reflect.DeepEqual(recorder.events, want) {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(recorder.events, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The report file is still written in full
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report JSONOutput
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("results.json is not valid JSON: %v\n%s", err, data)
+	}
+	if len(report.Tests) != 2 || !strings.Contains(report.Tests[0].Error, "unsupported runtime") {
+		t.Errorf("tests = %+v", report.Tests)
+	}
+}
+
+// sleepingBridge serves every call with a long-running process
+type sleepingBridge struct {
+	processTracker
+}
+
+func (b *sleepingBridge) Call(functionName string, args []interface{}) (interface{}, error) {
+	_, err := b.run(exec.Command("sleep", "30"))
+	return nil, err
+}
+
+func TestCloseBridgeKillsInFlightCall(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	bridge := &sleepingBridge{}
+	done := make(chan error, 1)
+	go func() {
+		_, err := bridge.Call("slow", nil)
+		done <- err
+	}()
+
+	// Wait until the call's process is running
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		bridge.mu.Lock()
+		running := bridge.active != nil
+		bridge.mu.Unlock()
+		if running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("bridge process never started")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	closeBridge(bridge)
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected the killed call to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("closeBridge did not stop the in-flight call")
+	}
+
+	if _, err := bridge.Call("slow", nil); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Errorf("Call after close error = %v, want bridge is closed", err)
+	}
+}
@@ -0,0 +1,266 @@
+package runner
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	sarifSrcRoot = "%SRCROOT%" // uriBaseId of paths relative to the working directory
+)
+
+// SARIF 2.1.0 elements (the subset code review tools read)
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	ShortDescription sarifText `json:"shortDescription"`
+	Help             sarifText `json:"help"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifText              `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifText            `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifRules describes every error code as a SARIF rule
+func sarifRules() []sarifRule {
+	rules := make([]sarifRule, len(errorCodes))
+	for i, info := range errorCodes {
+		rules[i] = sarifRule{
+			ID:               string(info.Code),
+			Name:             info.Name,
+			ShortDescription: sarifText{info.Description},
+			Help:             sarifText{info.Help},
+		}
+	}
+	return rules
+}
+
+// sarifReporter collects failing tests and writes a SARIF log when the run finishes,
+// so failures show up in code scanning and review tools
+type sarifReporter struct {
+	baseReporter
+	out     io.Writer
+	reports []TestReport
+}
+
+func (r *sarifReporter) TestEnd(report TestReport) {
+	if report.Status == "fail" {
+		r.reports = append(r.reports, report)
+	}
+}
+
+func (r *sarifReporter) Finish(summary TestSummary) error {
+	encoder := json.NewEncoder(r.out)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(newSARIFLog(r.reports))
+}
+
+// newSARIFLog renders failing tests as a SARIF log: one result per failure under the
+// rule for its error code, located at the test and, when a stack trace is available,
+// at the implementation line
+func newSARIFLog(failures []TestReport) sarifLog {
+	rules := sarifRules()
+	ruleIndex := make(map[string]int)
+	for i, rule := range rules {
+		ruleIndex[rule.ID] = i
+	}
+
+	results := []sarifResult{}
+	for _, report := range failures {
+		result := report.Result
+		code := result.Code
+		if _, ok := ruleIndex[code]; !ok {
+			code = string(CodeRuntimeError)
+		}
+
+		message := result.Name + ": " + result.Error
+		if result.Actual != nil || result.Expected != nil {
+			message += "\nactual: " + formatValue(result.Actual) + "\nexpected: " + formatValue(result.Expected)
+		}
+
+		testLocation := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact(report.File)},
+			Message:          &sarifText{"Test '" + result.Name + "'"},
+		}
+		if report.Test != nil && report.Test.Line > 0 {
+			testLocation.PhysicalLocation.Region = &sarifRegion{StartLine: report.Test.Line}
+		}
+		locations := []sarifLocation{testLocation}
+		if file, line, ok := stackLocation(result.Stack); ok {
+			locations = append(locations, sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifact(file),
+					Region:           &sarifRegion{StartLine: line},
+				},
+				Message: &sarifText{"Error thrown here"},
+			})
+		}
+
+		properties := map[string]interface{}{"confidence": result.Confidence}
+		if ref := stepRef(result); ref != "" {
+			properties["failed_step"] = ref
+		}
+		if result.Actual != nil || result.Expected != nil {
+			properties["actual"] = result.Actual
+			properties["expected"] = result.Expected
+		}
+
+		results = append(results, sarifResult{
+			RuleID:     code,
+			RuleIndex:  ruleIndex[code],
+			Level:      "error",
+			Message:    sarifText{message},
+			Locations:  locations,
+			Properties: properties,
+		})
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "vyb",
+				Version:        Version,
+				InformationURI: "https://github.com/vybtest/vyb",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// sarifArtifact makes a path relative to the working directory, which code scanning
+// resolves against the repository root
+func sarifArtifact(path string) sarifArtifactLocation {
+	if rel, ok := relativeToWorkDir(path); ok {
+		return sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: sarifSrcRoot}
+	}
+	return sarifArtifactLocation{URI: "file://" + filepath.ToSlash(path)}
+}
+
+// relativeToWorkDir returns path relative to the working directory, if it is inside it
+func relativeToWorkDir(path string) (string, bool) {
+	if !filepath.IsAbs(path) {
+		return filepath.Clean(path), !strings.HasPrefix(filepath.Clean(path), "..")
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return rel, true
+}
+
+// Stack frame formats of the bridge runtimes
+var (
+	nodeFrame   = regexp.MustCompile(`^\s*at (?:.*\()?(?:file://)?(.+?):(\d+):\d+\)?$`) // at fn (/src/a.js:3:9)
+	pythonFrame = regexp.MustCompile(`^\s*File "(.+)", line (\d+)`)                     // File "/src/a.py", line 3, in fn
+	luaFrame    = regexp.MustCompile(`^\s*(.+?\.lua):(\d+):`)                           // /src/a.lua:3: in function 'fn'
+)
+
+// stackLocation finds where an external function threw: the innermost stack frame
+// that belongs to the project rather than the bridge, the runtime or a library.
+// Frames outside the working directory are only used when there is nothing better.
+func stackLocation(stack string) (file string, line int, ok bool) {
+	type frame struct {
+		file string
+		line int
+	}
+
+	var frames []frame
+	innermostLast := false // Python prints the innermost frame last, Node and Lua first
+	for _, text := range strings.Split(stack, "\n") {
+		var match []string
+		if match = pythonFrame.FindStringSubmatch(text); match != nil {
+			innermostLast = true
+		} else if match = nodeFrame.FindStringSubmatch(text); match == nil {
+			match = luaFrame.FindStringSubmatch(text)
+		}
+		if match == nil {
+			continue
+		}
+		n, err := strconv.Atoi(match[2])
+		if err != nil || strings.Contains(match[1], "vyb_bridge") ||
+			strings.HasPrefix(match[1], "node:") || strings.HasPrefix(match[1], "internal/") {
+			continue
+		}
+		frames = append(frames, frame{match[1], n})
+	}
+	if innermostLast {
+		for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+			frames[i], frames[j] = frames[j], frames[i]
+		}
+	}
+
+	for _, f := range frames {
+		if _, inside := relativeToWorkDir(f.file); inside {
+			return f.file, f.line, true
+		}
+	}
+	if len(frames) > 0 {
+		return frames[0].file, frames[0].line, true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestSARIFReporterEmitsFailures(t *testing.T) {
+	var out bytes.Buffer
+	reporter := NewReporter(OutputSARIF, &out)
+
+	reporter.TestEnd(TestReport{
+		File:   "math.vyb",
+		Status: "pass",
+		Result: parser.TestResult{Name: "adds", Passed: true},
+	})
+	reporter.TestEnd(TestReport{
+		File:   "math.vyb",
+		Status: "fail",
+		Result: parser.TestResult{Name: "doubles", Error: "Expectation failed: expect: r == 5", Code: "VYB_ASSERT_MISMATCH", Step: "then", Actual: 4.0, Expected: 5.0, Confidence: 0.9},
+		Test:   &parser.Test{Name: "doubles", Line: 7},
+	})
+	reporter.TestEnd(TestReport{
+		File:   "math.vyb",
+		Status: "fail",
+		Result: parser.TestResult{
+			Name:  "throws",
+			Error: "Failed to execute statement 'r = check(-1)': external function check() failed: external function error: negative input",
+			Code:  "VYB_EXTERNAL_ERROR",
+			Step:  "when",
+			Stack: "Error: negative input\n    at helper (calc.js:3:11)\n    at check (calc.js:7:28)\n    at Object.<anonymous> (/tmp/vyb_bridge.js:20:18)",
+		},
+		Test: &parser.Test{Name: "throws", Line: 12},
+	})
+	if err := reporter.Finish(TestSummary{}); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version = %q, runs = %d", log.Version, len(log.Runs))
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2 (failures only)", len(results))
+	}
+
+	mismatch := results[0]
+	if mismatch.RuleID != "VYB_ASSERT_MISMATCH" || log.Runs[0].Tool.Driver.Rules[mismatch.RuleIndex].ID != mismatch.RuleID {
+		t.Errorf("rule = %q (index %d)", mismatch.RuleID, mismatch.RuleIndex)
+	}
+	location := mismatch.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "math.vyb" || location.Region == nil || location.Region.StartLine != 7 {
+		t.Errorf("test location = %+v", location)
+	}
+
+	thrown := results[1]
+	if thrown.RuleID != "VYB_EXTERNAL_ERROR" {
+		t.Errorf("rule = %q, want VYB_EXTERNAL_ERROR", thrown.RuleID)
+	}
+	if len(thrown.Locations) != 2 {
+		t.Fatalf("got %d locations, want test and implementation", len(thrown.Locations))
+	}
+	impl := thrown.Locations[1].PhysicalLocation
+	if impl.ArtifactLocation.URI != "calc.js" || impl.Region.StartLine != 3 {
+		t.Errorf("implementation location = %s:%d, want calc.js:3", impl.ArtifactLocation.URI, impl.Region.StartLine)
+	}
+}
+
+func TestStackLocation(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack string
+		file  string
+		line  int
+	}{
+		{
+			name:  "node",
+			stack: "TypeError: x is undefined\n    at node:internal/foo:1:1\n    at total (src/cart.js:14:9)\n    at /tmp/vyb_bridge.js:30:18",
+			file:  "src/cart.js",
+			line:  14,
+		},
+		{
+			name:  "python innermost project frame",
+			stack: "Traceback (most recent call last):\n  File \"/tmp/vyb_bridge.py\", line 30, in <module>\n    result = fn(*request['args'])\n  File \"calc.py\", line 4, in boom\n    return json.loads(x)\n  File \"/usr/lib/python3/json/__init__.py\", line 346, in loads\nValueError: bad",
+			file:  "calc.py",
+			line:  4,
+		},
+		{
+			name:  "lua",
+			stack: "game.lua:8: boom\nstack traceback:\n\t[C]: in function 'error'\n\tgame.lua:8: in function 'hit'\n\t/tmp/vyb_bridge.lua:150: in function <...>",
+			file:  "game.lua",
+			line:  8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, line, ok := stackLocation(tt.stack)
+			if !ok || file != tt.file || line != tt.line {
+				t.Errorf("stackLocation() = %s:%d (%v), want %s:%d", file, line, ok, tt.file, tt.line)
+			}
+		})
+	}
+
+	if _, _, ok := stackLocation(""); ok {
+		t.Error("stackLocation(\"\") found a location")
+	}
+}
+
+func TestDecodeBridgeResponse(t *testing.T) {
+	response, ok := decodeBridgeResponse([]byte("debug output\n{\"error\": \"boom\", \"stack\": \"at f (a.js:1:1)\"}\n"))
+	if !ok {
+		t.Fatal("response not found after program output")
+	}
+	if _, err := response.value(); err == nil || err.Error() != "external function error: boom" {
+		t.Errorf("value() error = %v", err)
+	}
+
+	if _, ok := decodeBridgeResponse([]byte("SyntaxError: Unexpected token")); ok {
+		t.Error("crash output decoded as a response")
+	}
+}
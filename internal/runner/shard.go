@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// ParseShard parses a shard specification of the form "i/N" (1-based)
+func ParseShard(spec string) (int, int, error) {
+	parts := strings.Split(spec, "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid shard %q (expected i/N, e.g. 1/4)", spec)
+	}
+
+	index, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	total, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q (expected i/N, e.g. 1/4)", spec)
+	}
+	if total < 1 || index < 1 || index > total {
+		return 0, 0, fmt.Errorf("invalid shard %q (index must be between 1 and %d)", spec, total)
+	}
+
+	return index, total, nil
+}
+
+// shardFiles returns the files assigned to shard index (1-based) of total.
+// Assignment depends only on the set of files and the durations, so every machine computes
+// the same disjoint partition as long as they share the same durations file. Without
+// durations, files are dealt out round-robin in sorted order; with durations, the slowest
+// files are placed first on the least loaded shard.
+func shardFiles(files []string, index, total int, durations map[string]float64) []string {
+	sorted := append([]string{}, files...)
+	sort.Strings(sorted)
+
+	assigned := make([]int, len(sorted))
+	if len(durations) == 0 {
+		for i := range sorted {
+			assigned[i] = i % total
+		}
+	} else {
+		// Files without history are assumed to take the average recorded time
+		var sum float64
+		for _, d := range durations {
+			sum += d
+		}
+		average := sum / float64(len(durations))
+
+		weight := func(file string) float64 {
+			if d, ok := durations[filepath.ToSlash(file)]; ok {
+				return d
+			}
+			return average
+		}
+
+		order := make([]int, len(sorted))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return weight(sorted[order[a]]) > weight(sorted[order[b]])
+		})
+
+		loads := make([]float64, total)
+		for _, i := range order {
+			target := 0
+			for shard := 1; shard < total; shard++ {
+				if loads[shard] < loads[target] {
+					target = shard
+				}
+			}
+			assigned[i] = target
+			loads[target] += weight(sorted[i])
+		}
+	}
+
+	var result []string
+	for i, file := range sorted {
+		if assigned[i] == index-1 {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// durationsPath returns the shared durations file to balance shards by and record
+// into: the --durations flag, else the config's durations setting, relative to dir.
+// Empty when neither is set, so runs never depend on a machine-local copy.
+func durationsPath(dir string, config *parser.Config, flag string) string {
+	path := flag
+	if path == "" && config != nil {
+		path = config.Durations
+	}
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// loadDurations reads per-file durations recorded by previous runs (nil if none)
+func loadDurations(path string) map[string]float64 {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var durations map[string]float64
+	if err := json.Unmarshal(data, &durations); err != nil {
+		return nil
+	}
+	return durations
+}
+
+// saveDurations merges the durations of this run into the durations file
+func saveDurations(path string, durations map[string]float64) error {
+	if len(durations) == 0 {
+		return nil
+	}
+
+	merged := loadDurations(path)
+	if merged == nil {
+		merged = make(map[string]float64)
+	}
+	for file, d := range durations {
+		merged[filepath.ToSlash(file)] = d
+	}
+
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
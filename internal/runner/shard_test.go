@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestParseShard(t *testing.T) {
+	index, total, err := ParseShard("2/4")
+	if err != nil {
+		t.Fatalf("ParseShard failed: %v", err)
+	}
+	if index != 2 || total != 4 {
+		t.Errorf("Expected 2/4, got %d/%d", index, total)
+	}
+
+	for _, spec := range []string{"0/4", "5/4", "2", "a/b", "1/0"} {
+		if _, _, err := ParseShard(spec); err == nil {
+			t.Errorf("Expected error for shard %q", spec)
+		}
+	}
+}
+
+func checkPartition(t *testing.T, files []string, total int, durations map[string]float64) [][]string {
+	t.Helper()
+
+	seen := make(map[string]int)
+	var shards [][]string
+	for index := 1; index <= total; index++ {
+		shard := shardFiles(files, index, total, durations)
+		for _, file := range shard {
+			seen[file]++
+		}
+		shards = append(shards, shard)
+	}
+
+	for _, file := range files {
+		if seen[file] != 1 {
+			t.Errorf("Expected %s in exactly one shard, found in %d", file, seen[file])
+		}
+	}
+	return shards
+}
+
+func TestShardFilesRoundRobin(t *testing.T) {
+	files := []string{"e.vyb", "a.vyb", "d.vyb", "b.vyb", "c.vyb"}
+
+	shards := checkPartition(t, files, 2, nil)
+	if len(shards[0]) != 3 || len(shards[1]) != 2 {
+		t.Errorf("Expected shards of 3 and 2 files, got %v", shards)
+	}
+
+	// Order of the input must not affect the assignment
+	reversed := []string{"c.vyb", "b.vyb", "d.vyb", "a.vyb", "e.vyb"}
+	again := shardFiles(reversed, 1, 2, nil)
+	for i := range again {
+		if again[i] != shards[0][i] {
+			t.Errorf("Expected deterministic assignment, got %v and %v", shards[0], again)
+			break
+		}
+	}
+}
+
+func TestShardFilesBalancedByDuration(t *testing.T) {
+	files := []string{"slow.vyb", "a.vyb", "b.vyb", "c.vyb"}
+	durations := map[string]float64{"slow.vyb": 10, "a.vyb": 3, "b.vyb": 3, "c.vyb": 3}
+
+	shards := checkPartition(t, files, 2, durations)
+	if len(shards[0]) != 1 || shards[0][0] != "slow.vyb" {
+		t.Errorf("Expected slow file alone on first shard, got %v", shards)
+	}
+}
+
+func TestDurationsOnlyWithSharedFile(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.WriteFile("a.vyb", []byte("adds:\n  when:\n    - x = add(1, 1)\n  then:\n    - \"expect: x == 2\"\n"), 0644)
+	opts := Options{Reporters: []ReporterSpec{{Format: OutputJSON, Output: filepath.Join("out", "results.json")}}}
+
+	// Without a durations file nothing is recorded, so shards never depend on local state
+	if err := runFiles([]string{"a.vyb"}, opts); err != nil {
+		t.Fatalf("runFiles failed: %v", err)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(".vyb", "*durations*")); len(entries) > 0 {
+		t.Errorf("Expected no durations recorded, found %v", entries)
+	}
+
+	opts.Durations = filepath.Join("ci", "durations.json")
+	if err := runFiles([]string{"a.vyb"}, opts); err != nil {
+		t.Fatalf("runFiles failed: %v", err)
+	}
+	durations := loadDurations(durationsPath(dir, nil, opts.Durations))
+	if _, ok := durations["a.vyb"]; !ok || len(durations) != 1 {
+		t.Errorf("Expected a.vyb recorded in the shared file, got %v", durations)
+	}
+}
+
+func TestDurationsPath(t *testing.T) {
+	config := &parser.Config{Durations: "ci/durations.json"}
+	tests := []struct {
+		flag   string
+		config *parser.Config
+		want   string
+	}{
+		{"", nil, ""},
+		{"", config, filepath.Join("/project", "ci", "durations.json")},
+		{"timings.json", config, filepath.Join("/project", "timings.json")},
+		{"/shared/timings.json", config, "/shared/timings.json"},
+	}
+
+	for _, tt := range tests {
+		if got := durationsPath("/project", tt.config, tt.flag); got != tt.want {
+			t.Errorf("durationsPath(%q) = %q, want %q", tt.flag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// newSeed returns a fresh seed for --shuffle when none was given
+func newSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// shuffleFiles returns the files in a random order determined by seed
+func shuffleFiles(files []string, seed int64) []string {
+	shuffled := append([]string{}, files...)
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+// shuffleTests reorders a file's tests in place. The order depends only on the seed
+// and the file name, so it is reproducible regardless of which other files are run.
+func shuffleTests(filename string, tests []parser.Test, seed int64) {
+	h := fnv.New64a()
+	h.Write([]byte(filename))
+
+	rng := rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+	rng.Shuffle(len(tests), func(i, j int) {
+		tests[i], tests[j] = tests[j], tests[i]
+	})
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestShuffleFilesIsDeterministic(t *testing.T) {
+	files := []string{"a.vyb", "b.vyb", "c.vyb", "d.vyb", "e.vyb", "f.vyb", "g.vyb", "h.vyb"}
+	original := append([]string{}, files...)
+
+	first := shuffleFiles(files, 42)
+	second := shuffleFiles(files, 42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Same seed gave different orders: %v and %v", first, second)
+	}
+	if !reflect.DeepEqual(files, original) {
+		t.Errorf("shuffleFiles modified its input: %v", files)
+	}
+
+	sorted := append([]string{}, first...)
+	sort.Strings(sorted)
+	if !reflect.DeepEqual(sorted, original) {
+		t.Errorf("Shuffled files %v are not a permutation of %v", first, original)
+	}
+
+	if reflect.DeepEqual(shuffleFiles(files, 42), shuffleFiles(files, 7)) {
+		t.Error("Expected different seeds to give different orders")
+	}
+}
+
+func TestShuffleTestsIsDeterministic(t *testing.T) {
+	newTests := func() []parser.Test {
+		var tests []parser.Test
+		for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+			tests = append(tests, parser.Test{Name: name})
+		}
+		return tests
+	}
+	names := func(tests []parser.Test) []string {
+		var names []string
+		for _, test := range tests {
+			names = append(names, test.Name)
+		}
+		return names
+	}
+
+	first, second := newTests(), newTests()
+	shuffleTests("math.vyb", first, 42)
+	shuffleTests("math.vyb", second, 42)
+	if !reflect.DeepEqual(names(first), names(second)) {
+		t.Errorf("Same seed and file gave different orders: %v and %v", names(first), names(second))
+	}
+
+	// The order depends on the file, so files with the same test names differ
+	other := newTests()
+	shuffleTests("strings.vyb", other, 42)
+	if reflect.DeepEqual(names(first), names(other)) {
+		t.Error("Expected different files to be shuffled differently")
+	}
+}
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tapDiagnostic is the YAML block attached to a TAP test point. It carries the same
+// failure data as the suggest format.
+type tapDiagnostic struct {
+	Message        string      `yaml:"message,omitempty"`
+	Severity       string      `yaml:"severity"`
+	File           string      `yaml:"file"`
+	Code           string      `yaml:"code,omitempty"`
+	DidYouMean     []string    `yaml:"did_you_mean,omitempty"`
+	FailedStep     string      `yaml:"failed_step,omitempty"`
+	StepIndex      *int        `yaml:"step_index,omitempty"`
+	Actual         interface{} `yaml:"actual,omitempty"`
+	Expected       interface{} `yaml:"expected,omitempty"`
+	Diff           []string    `yaml:"diff,omitempty"`
+	Hints          []string    `yaml:"hints,omitempty"`
+	Confidence     float64     `yaml:"confidence"`
+	ConfidenceNote string      `yaml:"confidence_note,omitempty"`
+	Attempts       int         `yaml:"attempts,omitempty"`
+}
+
+// tapReporter streams TAP version 14 test points as tests complete
+type tapReporter struct {
+	baseReporter
+	out     io.Writer
+	started bool // TAP header written
+	count   int  // TAP test point number
+}
+
+// writeHeader writes the TAP version line once, before the first test point
+func (r *tapReporter) writeHeader() {
+	if !r.started {
+		r.started = true
+		fmt.Fprintln(r.out, "TAP version 14")
+	}
+}
+
+func (r *tapReporter) FileStart(file string) {
+	r.writeHeader()
+	fmt.Fprintf(r.out, "# %s\n", file)
+}
+
+// TestEnd streams a test point, with a YAML diagnostic for failures and flaky passes
+func (r *tapReporter) TestEnd(report TestReport) {
+	r.writeHeader()
+	r.count++
+
+	result := report.Result
+	if report.Status == "not_run" {
+		fmt.Fprintf(r.out, "ok %d - %s # SKIP %s\n", r.count, tapEscape(result.Name), notRunMessage(report))
+		return
+	}
+
+	okText := "ok"
+	if !result.Passed {
+		okText = "not ok"
+	}
+	fmt.Fprintf(r.out, "%s %d - %s\n", okText, r.count, tapEscape(result.Name))
+
+	if result.Passed && !result.Flaky {
+		return
+	}
+
+	diagnostic := tapDiagnostic{
+		Message:    result.Error,
+		Severity:   report.Status,
+		File:       report.File,
+		Code:       result.Code,
+		DidYouMean: result.Suggestions,
+		Confidence: result.Confidence,
+		Attempts:   report.Attempts,
+	}
+	if report.Test != nil {
+		suggestResult := newSuggestResult(report)
+		diagnostic.FailedStep = suggestResult.FailedStep
+		diagnostic.StepIndex = suggestResult.StepIndex
+		diagnostic.Actual = suggestResult.Actual
+		diagnostic.Expected = suggestResult.Expected
+		diagnostic.Diff = suggestResult.Diff
+		diagnostic.Hints = suggestResult.Hints
+		diagnostic.ConfidenceNote = suggestResult.ConfidenceNote
+	}
+	if result.Flaky {
+		diagnostic.Message = fmt.Sprintf("flaky: passed on attempt %d", result.Attempts)
+	}
+
+	r.writeDiagnostic(diagnostic)
+}
+
+// writeDiagnostic writes a YAML diagnostic block indented under its test point
+func (r *tapReporter) writeDiagnostic(diagnostic tapDiagnostic) {
+	var buf strings.Builder
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(diagnostic); err != nil {
+		fmt.Fprintf(r.out, "# failed to encode diagnostics: %v\n", err)
+		return
+	}
+
+	fmt.Fprintln(r.out, "  ---")
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		fmt.Fprintf(r.out, "  %s\n", line)
+	}
+	fmt.Fprintln(r.out, "  ...")
+}
+
+// Finish closes the stream with the plan and summary comments
+func (r *tapReporter) Finish(summary TestSummary) error {
+	r.writeHeader()
+	fmt.Fprintf(r.out, "1..%d\n", r.count)
+	fmt.Fprintf(r.out, "# pass %d\n", summary.Passed)
+	fmt.Fprintf(r.out, "# fail %d\n", summary.Failed)
+	if summary.Flaky > 0 {
+		fmt.Fprintf(r.out, "# flaky %d\n", summary.Flaky)
+	}
+	if summary.NotRun > 0 {
+		fmt.Fprintf(r.out, "# not run %d\n", summary.NotRun)
+	}
+	fmt.Fprintf(r.out, "# confidence avg %.2f, min %.2f, max %.2f\n",
+		summary.AverageConfidence, summary.MinConfidence, summary.MaxConfidence)
+	if summary.Seed != 0 {
+		fmt.Fprintf(r.out, "# seed %d\n", summary.Seed)
+	}
+	if summary.Shard != "" {
+		fmt.Fprintf(r.out, "# shard %s\n", summary.Shard)
+	}
+	return nil
+}
+
+// tapEscape escapes characters with special meaning in a TAP description
+func tapEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "#", `\#`)
+}
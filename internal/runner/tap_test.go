@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTAPReporter(t *testing.T) {
+	var out bytes.Buffer
+	reporter := NewReporter(OutputTAP, &out)
+
+	failing := &parser.Test{Name: "doubles #1", Confidence: 0.9, When: []string{"x = double(2)"}, Then: []string{"expect: x == 5"}}
+	reporter.RunStart(RunInfo{Files: 1, Seed: 42})
+	reporter.FileStart("math.vyb")
+	reporter.TestEnd(TestReport{File: "math.vyb", Status: "pass", Result: parser.TestResult{Name: "adds", Passed: true, Confidence: 1}})
+	reporter.TestEnd(TestReport{
+		File:   "math.vyb",
+		Status: "fail",
+		Result: parser.TestResult{
+			Name: failing.Name, Error: "Expectation failed: expect: x == 5", Code: string(CodeAssertMismatch),
+			Step: "then", StepIndex: 0, Confidence: 0.9, Actual: 4.0, Expected: 5.0, Operator: "==",
+		},
+		Test:  failing,
+		Hints: []string{"Check double()"},
+	})
+	reporter.TestEnd(TestReport{File: "math.vyb", Status: "flaky", Attempts: 2, Result: parser.TestResult{Name: "retried", Passed: true, Flaky: true, Attempts: 2, Confidence: 0.8}})
+	reporter.TestEnd(TestReport{File: "math.vyb", Status: "not_run", Reason: "stopped", Result: parser.TestResult{Name: "skipped", Confidence: 0.5}})
+	reporter.FileEnd("math.vyb", FileSummary{})
+	if err := reporter.Finish(TestSummary{Total: 4, Passed: 2, Failed: 1, Flaky: 1, NotRun: 1, AverageConfidence: 0.9, MinConfidence: 0.8, MaxConfidence: 1, Seed: 42}); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	var points []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "ok ") || strings.HasPrefix(line, "not ok ") || strings.HasPrefix(line, "1..") {
+			points = append(points, line)
+		}
+	}
+	want := []string{
+		"ok 1 - adds",
+		`not ok 2 - doubles \#1`,
+		"ok 3 - retried",
+		"ok 4 - skipped # SKIP not run (stopped)",
+		"1..4",
+	}
+	if strings.Join(points, "\n") != strings.Join(want, "\n") {
+		t.Errorf("test points:\n%s\nwant:\n%s", strings.Join(points, "\n"), strings.Join(want, "\n"))
+	}
+	if lines[0] != "TAP version 14" || lines[1] != "# math.vyb" {
+		t.Errorf("Expected version line and file comment first, got %q", lines[:2])
+	}
+	for _, comment := range []string{"# pass 2", "# fail 1", "# flaky 1", "# not run 1", "# seed 42"} {
+		if !strings.Contains(out.String(), comment+"\n") {
+			t.Errorf("Missing summary comment %q in:\n%s", comment, out.String())
+		}
+	}
+
+	// The failure's YAML block sits between "  ---" and "  ..." right after its test point
+	start := strings.Index(out.String(), "not ok 2 - doubles \\#1\n  ---\n")
+	if start < 0 {
+		t.Fatalf("No diagnostic block after the failing test point:\n%s", out.String())
+	}
+	block := out.String()[start:]
+	block = block[strings.Index(block, "  ---\n")+len("  ---\n") : strings.Index(block, "  ...\n")]
+
+	var diagnostic tapDiagnostic
+	if err := yaml.Unmarshal([]byte(block), &diagnostic); err != nil {
+		t.Fatalf("Diagnostic is not YAML: %v\n%s", err, block)
+	}
+	if diagnostic.Severity != "fail" || diagnostic.File != "math.vyb" || diagnostic.Code != string(CodeAssertMismatch) ||
+		diagnostic.FailedStep != "then" || diagnostic.StepIndex == nil || diagnostic.Actual != 4 || diagnostic.Expected != 5 ||
+		len(diagnostic.Hints) == 0 || diagnostic.Hints[0] != "Check double()" {
+		t.Errorf("diagnostic = %+v", diagnostic)
+	}
+
+	if !strings.Contains(out.String(), "ok 3 - retried\n  ---\n  message: 'flaky: passed on attempt 2'\n") {
+		t.Errorf("Expected a flaky diagnostic for the retried test:\n%s", out.String())
+	}
+}
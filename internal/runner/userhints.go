@@ -0,0 +1,378 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vybtest/vyb/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// hintsFile holds project hint rules kept outside vyb.config.yaml
+const hintsFile = ".vyb/hints.yaml"
+
+// hintRule is a validated parser.HintRule, ready to match against results
+type hintRule struct {
+	parser.HintRule
+	function *regexp.Regexp
+	file     *regexp.Regexp
+	actual   valuePredicate
+	expected valuePredicate
+}
+
+// valuePredicate tests an actual or expected value
+type valuePredicate func(v interface{}) bool
+
+// loadHintRules collects the hint rules from vyb.config.yaml and .vyb/hints.yaml, in
+// that order. config may be nil.
+func loadHintRules(dir string, config *parser.Config) ([]hintRule, error) {
+	var rules []hintRule
+
+	if config != nil {
+		compiled, err := compileHintRules(config.Hints, "vyb.config.yaml")
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, compiled...)
+	}
+
+	fileRules, err := readHintsFile(filepath.Join(dir, hintsFile))
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := compileHintRules(fileRules, hintsFile)
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, compiled...), nil
+}
+
+// readHintsFile reads hint rules from a file holding either a list of rules or a
+// "hints:" key like vyb.config.yaml (nil if the file doesn't exist)
+func readHintsFile(path string) ([]parser.HintRule, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", hintsFile, err)
+	}
+
+	var list []parser.HintRule
+	if err := yaml.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+
+	var file struct {
+		Hints []parser.HintRule `yaml:"hints"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", hintsFile, err)
+	}
+	return file.Hints, nil
+}
+
+// compileHintRules validates rules and compiles their globs and predicates
+func compileHintRules(rules []parser.HintRule, source string) ([]hintRule, error) {
+	compiled := make([]hintRule, 0, len(rules))
+	for i, rule := range rules {
+		c, err := compileHintRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hint rule %d in %s: %w", i+1, source, err)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func compileHintRule(rule parser.HintRule) (hintRule, error) {
+	c := hintRule{HintRule: rule}
+
+	if strings.TrimSpace(rule.Hint) == "" {
+		return c, fmt.Errorf("missing hint text")
+	}
+	if c.specificity() == 0 {
+		return c, fmt.Errorf("no conditions (set code, function, file, actual or expected)")
+	}
+	if rule.Code != "" && !knownErrorCode(ErrorCode(rule.Code)) {
+		return c, fmt.Errorf("unknown error code %q", rule.Code)
+	}
+
+	var err error
+	if rule.Function != "" {
+		if c.function, err = globPattern(rule.Function); err != nil {
+			return c, fmt.Errorf("function: %w", err)
+		}
+	}
+	if rule.File != "" {
+		if c.file, err = globPattern(rule.File); err != nil {
+			return c, fmt.Errorf("file: %w", err)
+		}
+	}
+	if rule.Actual != "" {
+		if c.actual, err = parseValuePredicate(rule.Actual); err != nil {
+			return c, fmt.Errorf("actual: %w", err)
+		}
+	}
+	if rule.Expected != "" {
+		if c.expected, err = parseValuePredicate(rule.Expected); err != nil {
+			return c, fmt.Errorf("expected: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// specificity is the number of conditions a rule sets; more specific rules come first
+func (r hintRule) specificity() int {
+	n := 0
+	for _, condition := range []string{r.Code, r.Function, r.File, r.Actual, r.Expected} {
+		if condition != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// match reports whether the rule applies to a failed test and returns its hint with
+// placeholders filled in
+func (r hintRule) match(file string, result parser.TestResult, test *parser.Test) (string, bool) {
+	if r.Code != "" && r.Code != result.Code {
+		return "", false
+	}
+	if r.file != nil && !matchesFile(r.file, file) {
+		return "", false
+	}
+
+	// Values are only known when an expectation was evaluated
+	hasValues := ErrorCode(result.Code) == CodeAssertMismatch
+	if r.actual != nil && (!hasValues || !r.actual(result.Actual)) {
+		return "", false
+	}
+	if r.expected != nil && (!hasValues || !r.expected(result.Expected)) {
+		return "", false
+	}
+
+	// A failed call is the function the failure is about; otherwise consider every call
+	var candidates []string
+	if result.Function != "" {
+		candidates = []string{result.Function}
+	} else if test != nil {
+		candidates = calledFunctions(test)
+	}
+
+	var function string
+	for _, name := range candidates {
+		if r.function == nil || r.function.MatchString(name) {
+			function = name
+			break
+		}
+	}
+	if r.function != nil && function == "" {
+		return "", false
+	}
+
+	hint := strings.NewReplacer(
+		"{actual}", formatValue(result.Actual),
+		"{expected}", formatValue(result.Expected),
+		"{function}", function,
+		"{test}", result.Name,
+		"{file}", file,
+	).Replace(r.Hint)
+	return hint, true
+}
+
+// projectHints returns the hints of the rules matching a failed test, most specific
+// first; rules equally specific keep their declaration order
+func projectHints(rules []hintRule, file string, result parser.TestResult, test *parser.Test) []string {
+	type matched struct {
+		hint        string
+		specificity int
+	}
+
+	var matches []matched
+	for _, rule := range rules {
+		if hint, ok := rule.match(file, result, test); ok {
+			matches = append(matches, matched{hint, rule.specificity()})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].specificity > matches[j].specificity
+	})
+
+	hints := make([]string, len(matches))
+	for i, m := range matches {
+		hints[i] = m.hint
+	}
+	return hints
+}
+
+// mergeHints puts project hints before the built-in ones, dropping duplicates
+func mergeHints(project, builtin []string) []string {
+	seen := make(map[string]bool)
+	var hints []string
+	for _, hint := range append(append([]string{}, project...), builtin...) {
+		if !seen[hint] {
+			seen[hint] = true
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// globPattern compiles a glob where * and ? stay within one path segment and **
+// matches across segments
+func globPattern(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				b.WriteString("(.*/)?") // Zero or more directories
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				b.WriteString(".*")
+				i++
+			default:
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// matchesFile reports whether a file glob matches a test file's path, or its name
+// when the glob has no directory part
+func matchesFile(re *regexp.Regexp, file string) bool {
+	return re.MatchString(filepath.ToSlash(filepath.Clean(file))) || re.MatchString(filepath.Base(file))
+}
+
+// parseValuePredicate parses a predicate on a value:
+//
+//	null, empty, type <name>, contains <text>, matches <regex>,
+//	<op> <value> with op one of == != > < >= <=, or a bare value (same as ==)
+//
+// Values are written as YAML scalars or flow collections, e.g. 0, "abc", [1, 2].
+func parseValuePredicate(text string) (valuePredicate, error) {
+	text = strings.TrimSpace(text)
+	keyword, rest, _ := strings.Cut(text, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch {
+	case text == "null":
+		return func(v interface{}) bool { return v == nil }, nil
+	case text == "empty":
+		return isEmptyValue, nil
+	case keyword == "type":
+		switch rest {
+		case "null", "number", "string", "boolean", "list", "object":
+		default:
+			return nil, fmt.Errorf("unknown type %q (use null, number, string, boolean, list or object)", rest)
+		}
+		return func(v interface{}) bool { return valueType(v) == rest }, nil
+	case keyword == "contains":
+		needle, err := parsePredicateValue(rest)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}) bool { return valueContains(v, needle) }, nil
+	case keyword == "matches":
+		re, err := regexp.Compile(rest)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}) bool { return re.MatchString(formatValue(v)) }, nil
+	}
+
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		if operand, ok := strings.CutPrefix(text, op); ok {
+			want, err := parsePredicateValue(operand)
+			if err != nil {
+				return nil, err
+			}
+			return comparePredicate(op, want), nil
+		}
+	}
+
+	want, err := parsePredicateValue(text)
+	if err != nil {
+		return nil, err
+	}
+	return comparePredicate("==", want), nil
+}
+
+// parsePredicateValue decodes the value in a predicate as YAML
+func parsePredicateValue(text string) (interface{}, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(text), &value); err != nil {
+		return nil, fmt.Errorf("invalid value %q: %w", text, err)
+	}
+	return value, nil
+}
+
+// comparePredicate compares a value with want; a value that can't be compared
+// doesn't match
+func comparePredicate(op string, want interface{}) valuePredicate {
+	return func(v interface{}) bool {
+		if v == nil || want == nil {
+			equal := v == nil && want == nil
+			return (op == "==" && equal) || (op == "!=" && !equal)
+		}
+		ok, err := compare(v, want, op)
+		return err == nil && ok
+	}
+}
+
+// isEmptyValue reports whether v is null, an empty string, list or object
+func isEmptyValue(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return val == ""
+	case []interface{}:
+		return len(val) == 0
+	case map[string]interface{}:
+		return len(val) == 0
+	}
+	return false
+}
+
+// valueContains reports whether a string contains a substring or a list contains an item
+func valueContains(v, needle interface{}) bool {
+	if list, ok := v.([]interface{}); ok {
+		for _, item := range list {
+			if ok, err := compare(item, needle, "=="); err == nil && ok {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(formatValue(v), formatValue(needle))
+}
+
+// knownErrorCode reports whether code is one of the documented error codes
+func knownErrorCode(code ErrorCode) bool {
+	for _, info := range errorCodes {
+		if info.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestParseValuePredicate(t *testing.T) {
+	tests := []struct {
+		predicate string
+		value     interface{}
+		want      bool
+	}{
+		{"null", nil, true},
+		{"null", 0.0, false},
+		{"empty", "", true},
+		{"empty", []interface{}{}, true},
+		{"empty", "x", false},
+		{"type string", "5", true},
+		{"type number", "5", false},
+		{"0", 0.0, true},
+		{"== 0", 1.0, false},
+		{"> 100", 150.0, true},
+		{"<= 100", 150.0, false},
+		{"!= null", 1.0, true},
+		{`"abc"`, "abc", true},
+		{"contains error", "an error occurred", true},
+		{"contains 3", []interface{}{1.0, 2.0, 3.0}, true},
+		{"matches ^[A-Z]+$", "ABC", true},
+		{"matches ^[A-Z]+$", "abc", false},
+		{"> 1", "text", false},
+	}
+
+	for _, tt := range tests {
+		predicate, err := parseValuePredicate(tt.predicate)
+		if err != nil {
+			t.Errorf("parseValuePredicate(%q) failed: %v", tt.predicate, err)
+			continue
+		}
+		if got := predicate(tt.value); got != tt.want {
+			t.Errorf("%q on %v = %v, want %v", tt.predicate, tt.value, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"type integer", "matches (", "==", "> [1"} {
+		if _, err := parseValuePredicate(invalid); err == nil {
+			t.Errorf("Expected error for predicate %q", invalid)
+		}
+	}
+}
+
+func TestGlobPattern(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"calc*", "calculateTotal", true},
+		{"*.py.vyb", "tests/cart.py.vyb", false},
+		{"tests/*.vyb", "tests/cart.py.vyb", true},
+		{"tests/*.vyb", "tests/billing/cart.py.vyb", false},
+		{"tests/**/*.vyb", "tests/cart.py.vyb", true},
+		{"tests/**/*.vyb", "tests/billing/cart.py.vyb", true},
+		{"get?", "getX", true},
+	}
+	for _, tt := range tests {
+		re, err := globPattern(tt.glob)
+		if err != nil {
+			t.Fatalf("globPattern(%q) failed: %v", tt.glob, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestProjectHints(t *testing.T) {
+	rules, err := compileHintRules([]parser.HintRule{
+		{Code: "VYB_ASSERT_MISMATCH", Hint: "Generic mismatch"},
+		{Function: "calc*", Actual: "null", Hint: "{function} returns null when the cart is empty"},
+		{Code: "VYB_ASSERT_MISMATCH", File: "billing/*.vyb", Expected: "> 0", Hint: "Billing totals are in cents, got {actual}"},
+		{Code: "VYB_UNKNOWN_FUNC", Hint: "Not for assertions"},
+	}, "vyb.config.yaml")
+	if err != nil {
+		t.Fatalf("compileHintRules failed: %v", err)
+	}
+
+	test := &parser.Test{When: []string{"total = calculateTotal(cart)"}, Then: []string{"expect: total == 1250"}}
+	result := parser.TestResult{Code: string(CodeAssertMismatch), Operator: "==", Actual: 12.5, Expected: 1250.0}
+
+	got := projectHints(rules, "billing/cart.js.vyb", result, test)
+	want := []string{"Billing totals are in cents, got 12.5", "Generic mismatch"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hints = %v, want %v", got, want)
+	}
+
+	result.Actual = nil
+	got = projectHints(rules, "other/cart.js.vyb", result, test)
+	want = []string{"calculateTotal returns null when the cart is empty", "Generic mismatch"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hints = %v, want %v", got, want)
+	}
+
+	// Value predicates never match failures without values
+	result = parser.TestResult{Code: string(CodeExternalError)}
+	if got := projectHints(rules, "billing/cart.js.vyb", result, test); len(got) != 0 {
+		t.Errorf("unexpected hints: %v", got)
+	}
+}
+
+func TestProjectHintsMatchFailedCall(t *testing.T) {
+	rules, err := compileHintRules([]parser.HintRule{
+		{Code: "VYB_EXTERNAL_ERROR", Function: "calc*", Hint: "{function} needs a cart"},
+		{Code: "VYB_EXTERNAL_ERROR", Function: "format*", Hint: "{function} needs a currency"},
+	}, "vyb.config.yaml")
+	if err != nil {
+		t.Fatalf("compileHintRules failed: %v", err)
+	}
+
+	test := &parser.Test{
+		When: []string{"total = calculateTotal(cart)", "label = formatPrice(total)"},
+		Then: []string{"expect: label == \"$12.50\""},
+	}
+
+	// Only the call that threw matches, not every function the test calls
+	result := parser.TestResult{Code: string(CodeExternalError), Function: "formatPrice"}
+	want := []string{"formatPrice needs a currency"}
+	if got := projectHints(rules, "cart.js.vyb", result, test); !reflect.DeepEqual(got, want) {
+		t.Errorf("hints = %v, want %v", got, want)
+	}
+
+	// Without a failed call, any function the test calls matches
+	result.Function = ""
+	want = []string{"calculateTotal needs a cart", "formatPrice needs a currency"}
+	if got := projectHints(rules, "cart.js.vyb", result, test); !reflect.DeepEqual(got, want) {
+		t.Errorf("hints = %v, want %v", got, want)
+	}
+}
+
+func TestCompileHintRulesErrors(t *testing.T) {
+	tests := []struct {
+		rule parser.HintRule
+		want string
+	}{
+		{parser.HintRule{Code: "VYB_ASSERT_MISMATCH"}, "missing hint text"},
+		{parser.HintRule{Hint: "x"}, "no conditions"},
+		{parser.HintRule{Hint: "x", Code: "VYB_NOPE"}, "unknown error code"},
+		{parser.HintRule{Hint: "x", Actual: "matches ("}, "actual"},
+	}
+	for _, tt := range tests {
+		_, err := compileHintRules([]parser.HintRule{{Hint: "ok", Code: "VYB_ASSERT_MISMATCH"}, tt.rule}, ".vyb/hints.yaml")
+		if err == nil || !strings.Contains(err.Error(), "rule 2 in .vyb/hints.yaml") || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("error = %v, want one about rule 2 containing %q", err, tt.want)
+		}
+	}
+}
+
+func TestLoadHintRules(t *testing.T) {
+	dir := t.TempDir()
+	config := &parser.Config{Hints: []parser.HintRule{{Code: "VYB_ASSERT_MISMATCH", Hint: "from config"}}}
+
+	rules, err := loadHintRules(dir, config)
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("rules = %v, err = %v", rules, err)
+	}
+
+	os.MkdirAll(filepath.Join(dir, ".vyb"), 0755)
+	for _, content := range []string{
+		"- code: VYB_ASSERT_MISMATCH\n  actual: 0\n  hint: from file\n",
+		"hints:\n  - code: VYB_ASSERT_MISMATCH\n    actual: 0\n    hint: from file\n",
+	} {
+		os.WriteFile(filepath.Join(dir, hintsFile), []byte(content), 0644)
+		rules, err := loadHintRules(dir, config)
+		if err != nil {
+			t.Fatalf("loadHintRules failed: %v", err)
+		}
+		if len(rules) != 2 || rules[1].Hint != "from file" || rules[1].Actual != "0" {
+			t.Errorf("rules = %+v", rules)
+		}
+	}
+}
+
+func TestTestEndMergesProjectHints(t *testing.T) {
+	rules, _ := compileHintRules([]parser.HintRule{{Code: "VYB_ASSERT_MISMATCH", Actual: "9", Hint: "Project hint"}}, "vyb.config.yaml")
+	collector := &reportCollector{}
+	m := &multiReporter{reporters: []Reporter{collector}}
+	m.SetHintRules(rules)
+
+	result := parser.TestResult{Code: string(CodeAssertMismatch), Operator: "==", Actual: 9.0, Expected: 10.0}
+	m.TestEnd("calc.js.vyb", result, &parser.Test{})
+
+	hints := collector.reports[0].Hints
+	if len(hints) < 3 || hints[0] != "Project hint" || !strings.HasPrefix(hints[1], "Off by one") {
+		t.Errorf("hints = %v", hints)
+	}
+}
+
+// reportCollector records the test reports it receives
+type reportCollector struct {
+	baseReporter
+	reports []TestReport
+}
+
+func (r *reportCollector) TestEnd(report TestReport) {
+	r.reports = append(r.reports, report)
+}
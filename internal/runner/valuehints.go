@@ -0,0 +1,164 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+// valueHints looks at the actual and expected values of a failed expectation and
+// names the pattern behind the difference (off by one, flipped sign, wrong unit, ...)
+func valueHints(result parser.TestResult) []string {
+	actual, expected := result.Actual, result.Expected
+
+	switch result.Operator {
+	case "==":
+	case ">", "<":
+		// A value sitting exactly on the boundary of a strict comparison
+		a, aOK := toFloat(actual)
+		e, eOK := toFloat(expected)
+		if aOK && eOK && a == e {
+			return []string{fmt.Sprintf("Actual equals the boundary %s - if it should pass, the comparison may need to be %s=", formatValue(expected), result.Operator)}
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	if actual == nil {
+		return []string{"The function returned null/undefined/None - check that every code path returns a value (missing return statement?)"}
+	}
+
+	if hint := typeMismatchHint(actual, expected); hint != "" {
+		return []string{hint}
+	}
+
+	if a, ok := toFloat(actual); ok {
+		if e, ok := toFloat(expected); ok {
+			return numberHints(a, e)
+		}
+	}
+
+	if a, ok := actual.(string); ok {
+		if e, ok := expected.(string); ok {
+			return stringHints(a, e)
+		}
+	}
+
+	if a, ok := actual.([]interface{}); ok {
+		if e, ok := expected.([]interface{}); ok && len(a) != len(e) {
+			return []string{listLengthHint(len(a), len(e))}
+		}
+	}
+
+	return nil
+}
+
+// typeMismatchHint explains values of different types, in particular numbers that
+// arrive as strings. "5" already equals 5 (compare falls back to the text of values),
+// so this is about numbers formatted differently, like "5.00".
+func typeMismatchHint(actual, expected interface{}) string {
+	actualType, expectedType := valueType(actual), valueType(expected)
+	if actualType == expectedType {
+		return ""
+	}
+
+	if s, ok := actual.(string); ok && expectedType == "number" {
+		if n, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			e, _ := toFloat(expected)
+			if n == e {
+				return fmt.Sprintf("Actual is the string %q but the number %s was expected - the function returns text instead of a number (parse it, or don't format it)", s, formatValue(expected))
+			}
+		}
+	}
+	if s, ok := expected.(string); ok && actualType == "number" {
+		if n, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			a, _ := toFloat(actual)
+			if n == a {
+				return fmt.Sprintf("Actual is the number %s but the string %q was expected - the function should return text", formatValue(actual), s)
+			}
+		}
+	}
+
+	return fmt.Sprintf("Type mismatch: actual is a %s but a %s was expected", actualType, expectedType)
+}
+
+// numberHints explains the difference between two numbers
+func numberHints(actual, expected float64) []string {
+	diff := actual - expected
+	scale := math.Max(1, math.Max(math.Abs(actual), math.Abs(expected)))
+
+	switch {
+	case math.Abs(diff) <= 1e-9*scale:
+		return []string{fmt.Sprintf("Floating-point rounding error (off by %g) - round the result or compare with a tolerance", diff)}
+	case math.Abs(diff) == 1:
+		direction := "more"
+		if diff < 0 {
+			direction = "less"
+		}
+		return []string{fmt.Sprintf("Off by one: actual is 1 %s than expected - check loop bounds, < vs <=, and 0- vs 1-based counting", direction)}
+	case expected != 0 && actual == -expected:
+		return []string{"The sign is flipped - check subtraction order (a - b vs b - a) or a negation"}
+	}
+
+	if actual == 0 || expected == 0 {
+		return nil
+	}
+
+	// Compare the ratio with a tolerance: 0.07 * 100 is not exactly 7
+	ratio := actual / expected
+	for _, factor := range []float64{10, 100, 1000} {
+		switch {
+		case math.Abs(ratio-factor) <= 1e-9*factor:
+			return []string{fmt.Sprintf("Actual is %gx the expected value - check for a percentage vs fraction or a unit conversion (e.g. cents vs dollars, ms vs s)", factor)}
+		case math.Abs(ratio-1/factor) <= 1e-9/factor:
+			return []string{fmt.Sprintf("Actual is 1/%g of the expected value - check for a percentage vs fraction or a unit conversion (e.g. dollars vs cents, s vs ms)", factor)}
+		}
+	}
+
+	return nil
+}
+
+// stringHints explains the difference between two strings
+func stringHints(actual, expected string) []string {
+	switch {
+	case strings.EqualFold(actual, expected):
+		return []string{"The strings differ only in letter case - check capitalization (toUpper/toLower)"}
+	case strings.Join(strings.Fields(actual), " ") == strings.Join(strings.Fields(expected), " "):
+		return []string{"The strings differ only in whitespace - check for leading/trailing spaces, newlines or double spaces (trim the value?)"}
+	case strings.EqualFold(strings.Join(strings.Fields(actual), " "), strings.Join(strings.Fields(expected), " ")):
+		return []string{"The strings differ only in letter case and whitespace"}
+	}
+	return nil
+}
+
+// listLengthHint explains lists of different lengths
+func listLengthHint(actual, expected int) string {
+	if actual < expected {
+		return fmt.Sprintf("The list has %d item(s) but %d were expected (%d missing) - check filters and loop bounds", actual, expected, expected-actual)
+	}
+	return fmt.Sprintf("The list has %d item(s) but %d were expected (%d extra) - check filters and duplicates", actual, expected, actual-expected)
+}
+
+// valueType names the JSON type of a value
+func valueType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	if _, ok := toFloat(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
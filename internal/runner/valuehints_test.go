@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vybtest/vyb/internal/parser"
+)
+
+func TestValueHints(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator string
+		actual   interface{}
+		expected interface{}
+		want     string // Substring of the first hint, empty for no hint
+	}{
+		{"off by one", "==", 9.0, 10.0, "Off by one: actual is 1 less"},
+		{"off by one int", "==", 11, 10.0, "Off by one: actual is 1 more"},
+		{"sign flip", "==", -5.0, 5.0, "sign is flipped"},
+		{"percentage", "==", 8.0, 0.08, "100x the expected value"},
+		{"cents", "==", 12.5, 1250.0, "1/100 of the expected value"},
+		{"inexact percentage", "==", 7.0, 0.07, "100x the expected value"},
+		{"inexact percentage 29", "==", 29.0, 0.29, "100x the expected value"},
+		{"inexact fraction", "==", 0.29, 29.0, "1/100 of the expected value"},
+		{"rounding", "==", 0.1 + 0.2, 0.3, "Floating-point rounding error"},
+		{"case", "==", "Hello", "hello", "differ only in letter case"},
+		{"whitespace", "==", " hello\n", "hello", "differ only in whitespace"},
+		{"number as string", "==", "5.00", 5.0, `string "5.00" but the number 5`},
+		{"string expected", "==", 5.0, "5.0", `number 5 but the string "5.0"`},
+		{"other types", "==", true, 1.0, "actual is a boolean but a number was expected"},
+		{"null", "==", nil, 5.0, "returned null/undefined/None"},
+		{"list length", "==", []interface{}{1.0, 2.0}, []interface{}{1.0, 2.0, 3.0}, "2 item(s) but 3 were expected (1 missing)"},
+		{"boundary", ">", 5.0, 5.0, "comparison may need to be >="},
+		{"unrelated numbers", "==", 7.0, 42.0, ""},
+		{"unrelated strings", "==", "cat", "dog", ""},
+		{"contains", "contains", "abc", "contains d", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hints := valueHints(parser.TestResult{Operator: tt.operator, Actual: tt.actual, Expected: tt.expected})
+			if tt.want == "" {
+				if len(hints) > 0 {
+					t.Errorf("unexpected hints: %v", hints)
+				}
+				return
+			}
+			if len(hints) == 0 || !strings.Contains(hints[0], tt.want) {
+				t.Errorf("hints = %v, want one containing %q", hints, tt.want)
+			}
+		})
+	}
+
+	// Hints are only shown for failed expectations; "5" == 5 passes
+	if ok, _ := compare("5.00", 5.0, "=="); ok {
+		t.Error(`"5.00" == 5 should fail, so its type mismatch hint can be shown`)
+	}
+}
+
+func TestGenerateHintsPutsValueHintsFirst(t *testing.T) {
+	result := parser.TestResult{
+		Error:    "Expectation failed: expect: count == 10",
+		Code:     string(CodeAssertMismatch),
+		Operator: "==",
+		Actual:   9.0,
+		Expected: 10.0,
+	}
+	hints := generateHints(&parser.Test{}, result)
+	if len(hints) < 2 || !strings.HasPrefix(hints[0], "Off by one") {
+		t.Errorf("hints = %v", hints)
+	}
+}
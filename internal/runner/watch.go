@@ -1,75 +1,707 @@
 package runner
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
+	"unicode"
+
+	"github.com/vybtest/vyb/internal/parser"
 )
 
-// Watch watches for file changes and re-runs tests
-func Watch(pattern string, format OutputFormat) error {
-	fmt.Println("👀 Watch mode enabled - press Ctrl+C to stop")
-	fmt.Printf("Watching: %s\n\n", pattern)
+const (
+	pollInterval  = 500 * time.Millisecond // How often the polling watcher checks for changes
+	debounceDelay = 100 * time.Millisecond // Quiet time that ends a burst of changes
+	maxDebounce   = time.Second            // Longest a burst can delay a run
+)
 
-	// Track file modification times
-	fileModTimes := make(map[string]time.Time)
+// fileWatcher reports changes to the files in a set of directories
+type fileWatcher interface {
+	// Add watches a directory (not recursively)
+	Add(dir string) error
+	// Events delivers paths that were created, written, deleted or renamed. An empty
+	// path means changes may have been missed. The channel closes with the watcher.
+	Events() <-chan string
+	Close() error
+}
 
-	// Run tests initially
-	runOnce(pattern, format)
+// Watch watches test files and the modules they test, and re-runs the affected tests
+// when they change. In a terminal, keys select which tests run (see watchSession.command).
+func Watch(pattern string, opts Options) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
 
-	// Poll for changes every 500ms
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
+	watcher, err := newNotifyWatcher()
+	if err != nil {
+		watcher = newPollWatcher(pollInterval)
+	}
 
-	for range ticker.C {
-		changed := false
-		files, err := findTestFiles(pattern)
-		if err != nil {
+	keys, raw, restore := readKeys()
+	defer restore()
+	if raw {
+		// Ctrl+C still stops watch mode at once, but must put the terminal back first
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(interrupt)
+		go func() {
+			<-interrupt
+			restore()
+			os.Exit(130)
+		}()
+	}
+
+	if keys != nil {
+		fmt.Println("👀 Watch mode enabled - press q to quit")
+	} else {
+		fmt.Println("👀 Watch mode enabled - press Ctrl+C to stop")
+	}
+	fmt.Printf("Watching: %s\n\n", pattern)
+
+	session := &watchSession{
+		pattern: pattern,
+		opts:    opts,
+		dir:     cwd,
+		watcher: watcher,
+		keys:    keys,
+		raw:     raw,
+		failed:  make(map[string]map[string]bool),
+	}
+	defer func() { session.watcher.Close() }() // The watcher may be replaced by pollInstead
+	session.opts.observers = append(session.opts.observers, &failureTracker{failed: session.failed})
+	session.refresh()
+	session.run(session.files, "")
+
+	for !session.quit {
+		if len(session.pending) > 0 {
+			key, _ := session.nextKey()
+			session.command(key)
 			continue
 		}
 
-		// Check if any files changed
-		for _, file := range files {
-			info, err := os.Stat(file)
-			if err != nil {
+		select {
+		case path, ok := <-session.watcher.Events():
+			if !ok {
+				if err := session.pollInstead(); err != nil {
+					return err
+				}
+				continue
+			}
+			session.handle(collectChanges(path, session.watcher.Events(), debounceDelay, maxDebounce))
+		case key, ok := <-session.keys:
+			if !ok {
+				session.keys = nil // Input closed: keep watching files
 				continue
 			}
+			session.command(key)
+		}
+	}
+	return nil
+}
+
+// watchSession is the state of watch mode between runs
+type watchSession struct {
+	pattern string
+	opts    Options
+	dir     string // Project directory (where vyb.config.yaml lives)
+	watcher fileWatcher
+	config  *parser.Config
+	files   []string // Test files matching the pattern
+
+	keys    <-chan string // Keys pressed, or lines typed when raw is false (nil = no input)
+	raw     bool          // Keys arrive one at a time, without Enter
+	pending []string      // Keys typed during a run, handled once it finishes
+	quit    bool          // q was pressed
+
+	filePattern string                     // Only run test files matching this (empty = all)
+	testPattern string                     // Only run tests whose name contains this (empty = all)
+	failedOnly  bool                       // Only run tests that failed when they last ran
+	failed      map[string]map[string]bool // File -> names of the tests that failed when they last ran
+}
 
-			modTime := info.ModTime()
-			oldModTime, exists := fileModTimes[file]
-
-			if !exists {
-				// New file detected
-				fileModTimes[file] = modTime
-				changed = true
-			} else if modTime.After(oldModTime) {
-				// File was modified
-				fileModTimes[file] = modTime
-				changed = true
+// command handles a key (or a typed line, when input isn't raw). Returns false to quit.
+//
+//	a      run all tests, clearing the filters
+//	f      run failed tests only
+//	p      filter by file pattern
+//	t      filter by test name
+//	Enter  re-run with the current filters
+//	q      quit (during a run: stop after the current test, then quit)
+func (s *watchSession) command(input string) bool {
+	key, arg := s.parseInput(input)
+
+	switch key {
+	case "q", "\x04": // Ctrl+D
+		s.quit = true
+	case "a":
+		s.filePattern, s.testPattern, s.failedOnly = "", "", false
+		s.rerun()
+	case "f":
+		s.failedOnly = true
+		s.rerun()
+	case "p", "t":
+		label := "File pattern"
+		if key == "t" {
+			label = "Test name"
+		}
+		value, ok := arg, true
+		if value == "" {
+			value, ok = s.prompt(label)
+		}
+		if !ok {
+			s.waiting()
+			return true
+		}
+		if key == "p" {
+			s.filePattern = value
+		} else {
+			s.testPattern = value
+		}
+		s.rerun()
+	case "", "\n", "\r":
+		s.rerun()
+	}
+	return !s.quit
+}
+
+// parseInput splits input into its key and, for typed lines, the value after it
+func (s *watchSession) parseInput(input string) (key, arg string) {
+	if !s.raw && input != "" {
+		return input[:1], strings.TrimSpace(input[1:]) // "p calc" sets the pattern directly
+	}
+	return input, ""
+}
+
+// nextKey returns the next key, taking the keys typed during the last run first
+func (s *watchSession) nextKey() (string, bool) {
+	if len(s.pending) > 0 {
+		key := s.pending[0]
+		s.pending = s.pending[1:]
+		return key, true
+	}
+	key, ok := <-s.keys
+	return key, ok
+}
+
+// prompt reads a filter value. Returns false when cancelled with Esc.
+func (s *watchSession) prompt(label string) (string, bool) {
+	fmt.Printf("\n%s (empty to clear, Esc to cancel): ", label)
+	if !s.raw {
+		line, ok := s.nextKey()
+		return strings.TrimSpace(line), ok
+	}
+
+	// The terminal doesn't echo or edit in raw mode, so the prompt does
+	var value []rune
+	for {
+		key, ok := s.nextKey()
+		if !ok {
+			return "", false
+		}
+		switch key {
+		case "\n", "\r":
+			fmt.Println()
+			return strings.TrimSpace(string(value)), true
+		case "\x1b":
+			fmt.Println()
+			return "", false
+		case "\x7f", "\b":
+			if len(value) > 0 {
+				value = value[:len(value)-1]
+				fmt.Print("\b \b")
+			}
+		default:
+			if r := []rune(key)[0]; unicode.IsPrint(r) {
+				value = append(value, r)
+				fmt.Print(key)
 			}
 		}
+	}
+}
+
+// refresh re-reads the config and test files and watches every directory they live in:
+// the project directory, the test directories, and each module's directory
+func (s *watchSession) refresh() {
+	s.config, _ = parser.LoadConfig(s.dir) // A broken config is reported by the run
+	s.files, _ = findTestFiles(s.pattern)
+
+	dirs := []string{s.dir}
+	if isDir(s.pattern) {
+		dirs = append(dirs, s.pattern)
+	}
+	for _, file := range s.files {
+		dirs = append(dirs, filepath.Dir(file))
+	}
+	if s.config != nil {
+		for _, module := range s.config.Modules {
+			dirs = append(dirs, filepath.Dir(module))
+		}
+	}
 
-		if changed {
-			// Clear screen (cross-platform)
-			clearScreen()
+	for _, dir := range dirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			s.watcher.Add(abs) // Directories that don't exist (yet) are skipped
+		}
+	}
+}
+
+// pollInstead replaces a watcher that stopped on its own (e.g. reading inotify events
+// failed) with a polling watcher. It fails when polling already stopped.
+func (s *watchSession) pollInstead() error {
+	if _, ok := s.watcher.(*pollWatcher); ok {
+		return fmt.Errorf("watching for changes stopped unexpectedly")
+	}
 
-			// Show what changed
-			if format == OutputPretty {
-				fmt.Printf("🔄 Files changed, re-running tests...\n\n")
+	s.watcher.Close()
+	s.watcher = newPollWatcher(pollInterval)
+	s.refresh() // Watches the directories again
+	fmt.Println("Warning: file notifications stopped working, polling for changes instead")
+	return nil
+}
+
+// handle re-runs the test files affected by a burst of changes, within the filters
+func (s *watchSession) handle(changed []string) {
+	previous := s.files
+	s.refresh()
+
+	files, removed, reason := affectedTestFiles(previous, s.files, changed, s.config, s.dir)
+	for _, file := range removed {
+		delete(s.failed, file)
+	}
+	files = s.selected(files)
+	if len(files) == 0 && len(removed) == 0 {
+		return
+	}
+
+	clearScreen()
+	if s.opts.Pretty() {
+		for _, file := range removed {
+			fmt.Printf("🗑  %s was removed\n", file)
+		}
+	}
+	if len(files) == 0 {
+		s.waiting()
+		return
+	}
+	s.run(files, reason)
+}
+
+// rerun runs every test file within the filters
+func (s *watchSession) rerun() {
+	s.refresh()
+	files := s.selected(s.files)
+
+	clearScreen()
+	if len(files) == 0 {
+		if s.opts.Pretty() {
+			if s.failedOnly && s.filePattern == "" {
+				fmt.Println("✨ No failed tests to re-run")
+			} else {
+				fmt.Println("No test files match the filters")
 			}
+		}
+		s.waiting()
+		return
+	}
+	s.run(files, "")
+}
 
-			// Re-run tests
-			runOnce(pattern, format)
+// selected returns the files that pass the file pattern and, when only failed tests
+// run, that have failed tests
+func (s *watchSession) selected(files []string) []string {
+	var selected []string
+	for _, file := range files {
+		if s.filePattern != "" && !matchesFilePattern(s.filePattern, file) {
+			continue
+		}
+		if s.failedOnly && len(s.failed[file]) == 0 {
+			continue
+		}
+		selected = append(selected, file)
+	}
+	return selected
+}
+
+// testFilter selects the tests to run within the selected files (nil = all)
+func (s *watchSession) testFilter() func(string, *parser.Test) bool {
+	if s.testPattern == "" && !s.failedOnly {
+		return nil
+	}
+
+	pattern := strings.ToLower(s.testPattern)
+	failedOnly := s.failedOnly
+	failed := make(map[string]map[string]bool) // As of the start of the run
+	for file, names := range s.failed {
+		failed[file] = make(map[string]bool)
+		for name := range names {
+			failed[file][name] = true
+		}
+	}
+	return func(file string, test *parser.Test) bool {
+		if failedOnly && !failed[file][test.Name] {
+			return false
+		}
+		return strings.Contains(strings.ToLower(test.Name), pattern)
+	}
+}
 
-			if format == OutputPretty {
-				fmt.Printf("\n👀 Watching for changes...\n")
+// run runs test files and reports the outcome
+func (s *watchSession) run(files []string, reason string) {
+	if s.opts.Pretty() && len(files) < len(s.files) {
+		fmt.Printf("🔄 Re-running %d of %d test file(s): %s\n\n", len(files), len(s.files), strings.Join(files, ", "))
+	} else if s.opts.Pretty() && reason != "" {
+		fmt.Printf("🔄 Re-running all tests (%s)\n\n", reason)
+	}
+
+	stop := make(chan struct{})
+	opts := s.opts
+	opts.testFilter = s.testFilter()
+	opts.stop = stop
+
+	if s.keys != nil && s.opts.Pretty() {
+		fmt.Printf("%sPress q to stop%s\n\n", colorGray, colorReset)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		if len(files) == 0 {
+			done <- runOnce(s.pattern, opts) // Reports that nothing matches
+		} else {
+			done <- runFiles(files, opts)
+		}
+	}()
+
+	err := s.await(done, stop)
+	if err != nil && s.opts.Pretty() {
+		fmt.Fprintf(os.Stderr, "%s❌ %v%s\n", colorRed, err, colorReset)
+	}
+	if !s.quit {
+		s.waiting()
+	}
+}
+
+// await waits for a run to finish while reading keys: q stops the run after the
+// current test and quits, other keys wait until the run is over
+func (s *watchSession) await(done <-chan error, stop chan<- struct{}) error {
+	keys := s.keys
+	for {
+		select {
+		case err := <-done:
+			return err
+		case input, ok := <-keys:
+			if !ok {
+				keys = nil // Closed input is noticed again after the run
+				continue
 			}
+			if key, _ := s.parseInput(input); (key == "q" || key == "\x04") && !s.quit {
+				s.quit = true
+				close(stop)
+				continue
+			}
+			s.pending = append(s.pending, input)
 		}
 	}
+}
+
+// waiting shows the status line: the active filters and the keys
+func (s *watchSession) waiting() {
+	if !s.opts.Pretty() {
+		return
+	}
+
+	fmt.Printf("\n👀 Watching for changes...")
+	if filters := s.filters(); filters != "" {
+		fmt.Printf(" %sFilter: %s%s", colorYellow, filters, colorReset)
+	}
+	fmt.Println()
+
+	switch {
+	case s.keys == nil:
+	case s.raw:
+		fmt.Printf("%sPress a to run all, f to run failed, p to filter by file, t to filter by test name, Enter to re-run, q to quit%s\n", colorGray, colorReset)
+	default:
+		fmt.Printf("%sType a (all), f (failed), p <file pattern>, t <test name> or q (quit) and press Enter; Enter alone re-runs%s\n", colorGray, colorReset)
+	}
+}
+
+// filters describes the active filters (empty when everything runs)
+func (s *watchSession) filters() string {
+	var filters []string
+	if s.failedOnly {
+		filters = append(filters, "failed tests")
+	}
+	if s.filePattern != "" {
+		filters = append(filters, fmt.Sprintf("file %q", s.filePattern))
+	}
+	if s.testPattern != "" {
+		filters = append(filters, fmt.Sprintf("test %q", s.testPattern))
+	}
+	return strings.Join(filters, ", ")
+}
+
+// matchesFilePattern matches a test file against a glob or, without wildcards, a
+// case-insensitive part of its path
+func matchesFilePattern(pattern, file string) bool {
+	if strings.ContainsAny(pattern, "*?") {
+		re, err := globPattern(pattern)
+		return err == nil && matchesFile(re, file)
+	}
+	return strings.Contains(strings.ToLower(filepath.ToSlash(file)), strings.ToLower(filepath.ToSlash(pattern)))
+}
+
+// failureTracker records which tests failed when they last ran
+type failureTracker struct {
+	baseReporter
+	failed map[string]map[string]bool
+}
+
+func (f *failureTracker) TestEnd(report TestReport) {
+	name := report.Result.Name
+	switch report.Status {
+	case "fail":
+		if f.failed[report.File] == nil {
+			f.failed[report.File] = make(map[string]bool)
+		}
+		f.failed[report.File][name] = true
+	case "pass", "flaky":
+		delete(f.failed[report.File], name)
+		if len(f.failed[report.File]) == 0 {
+			delete(f.failed, report.File)
+		}
+	}
+}
 
+// readKeys reads the keyboard while watching. When stdin is a terminal that can be put
+// in raw mode, keys arrive as they are pressed; otherwise each typed line arrives.
+// Returns a nil channel when stdin isn't a terminal. restore puts the terminal back.
+func readKeys() (keys <-chan string, raw bool, restore func()) {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil, false, func() {}
+	}
+
+	restore, err = enableRawInput(os.Stdin)
+	raw = err == nil
+	if !raw {
+		restore = func() {}
+	}
+
+	ch := make(chan string, 16)
+	go func() {
+		defer close(ch)
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			if raw {
+				r, _, err := reader.ReadRune()
+				if err != nil {
+					return
+				}
+				ch <- string(r)
+				continue
+			}
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			ch <- strings.TrimRight(line, "\r\n")
+		}
+	}()
+	return ch, raw, restore
+}
+
+// affectedTestFiles decides which test files to re-run after changes: changed and new
+// test files, and tests calling functions of changed modules. It also returns the test
+// files that disappeared, and why everything is re-run when the changes can't be mapped.
+func affectedTestFiles(previous, current, changed []string, config *parser.Config, projectDir string) ([]string, []string, string) {
+	known := make(map[string]bool)
+	for _, file := range previous {
+		known[file] = true
+	}
+
+	var removed []string
+	stillThere := make(map[string]bool)
+	for _, file := range current {
+		stillThere[file] = true
+	}
+	for _, file := range previous {
+		if !stillThere[file] {
+			removed = append(removed, file)
+		}
+	}
+
+	for _, path := range changed {
+		if path == "" {
+			return current, removed, "changes may have been missed"
+		}
+	}
+
+	files, reason := selectAffectedFiles(current, changed, config, projectDir)
+
+	// New test files (created, or renamed into place) always run
+	selected := make(map[string]bool)
+	for _, file := range files {
+		selected[file] = true
+	}
+	for _, file := range current {
+		if !known[file] && !selected[file] {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	return files, removed, reason
+}
+
+// collectChanges gathers the burst of changes that starts with first, until the files
+// have been quiet for delay (or max has passed)
+func collectChanges(first string, events <-chan string, delay, max time.Duration) []string {
+	seen := map[string]bool{first: true}
+	changed := []string{first}
+	quiet := time.NewTimer(delay)
+	defer quiet.Stop()
+	deadline := time.After(max)
+
+	for {
+		select {
+		case path, ok := <-events:
+			if !ok {
+				return changed
+			}
+			if !seen[path] {
+				seen[path] = true
+				changed = append(changed, path)
+			}
+			quiet.Reset(delay)
+		case <-quiet.C:
+			return changed
+		case <-deadline:
+			return changed
+		}
+	}
+}
+
+// pollWatcher is the fallback fileWatcher for platforms without notifications: it
+// compares directory listings and modification times
+type pollWatcher struct {
+	events chan string
+	done   chan struct{}
+
+	mu        sync.Mutex
+	snapshots map[string]map[string]time.Time // Directory -> file -> modification time
+}
+
+// newPollWatcher creates a watcher that checks its directories every interval
+func newPollWatcher(interval time.Duration) fileWatcher {
+	w := &pollWatcher{
+		events:    make(chan string, 64),
+		done:      make(chan struct{}),
+		snapshots: make(map[string]map[string]time.Time),
+	}
+	go w.poll(interval)
+	return w
+}
+
+func (w *pollWatcher) Add(dir string) error {
+	if !isDir(dir) {
+		return fmt.Errorf("not a directory: %s", dir)
+	}
+
+	dir = filepath.Clean(dir)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.snapshots[dir]; !ok {
+		w.snapshots[dir] = listDir(dir)
+	}
+	return nil
+}
+
+func (w *pollWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *pollWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.done:
+	default:
+		close(w.done)
+	}
 	return nil
 }
 
+func (w *pollWatcher) poll(interval time.Duration) {
+	defer close(w.events)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		var changed []string
+		w.mu.Lock()
+		for dir, before := range w.snapshots {
+			after := listDir(dir)
+			changed = append(changed, diffSnapshots(before, after)...)
+			w.snapshots[dir] = after
+		}
+		w.mu.Unlock()
+
+		for _, path := range changed {
+			select {
+			case w.events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// listDir returns the modification times of the files in a directory
+func listDir(dir string) map[string]time.Time {
+	files := make(map[string]time.Time)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			files[filepath.Join(dir, entry.Name())] = info.ModTime()
+		}
+	}
+	return files
+}
+
+// diffSnapshots returns the files that were added, modified or removed, sorted
+func diffSnapshots(before, after map[string]time.Time) []string {
+	var changed []string
+	for path, modTime := range after {
+		if previous, ok := before[path]; !ok || !modTime.Equal(previous) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
 // clearScreen clears the terminal screen (cross-platform)
 func clearScreen() {
 	// ANSI escape code to clear screen and move cursor to top
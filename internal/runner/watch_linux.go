@@ -0,0 +1,148 @@
+//go:build linux
+
+package runner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask selects the events that can change which tests pass: writes, creations,
+// deletions and renames in a directory, and the directory itself going away
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// inotifyWatcher reports changes in watched directories using Linux inotify
+type inotifyWatcher struct {
+	fd     int      // The inotify descriptor
+	file   *os.File // fd for reading; closing it stops the reader
+	events chan string
+
+	mu   sync.Mutex
+	dirs map[int32]string // Watch descriptor -> directory
+	wds  map[string]int32 // Directory -> watch descriptor
+}
+
+// newNotifyWatcher creates a watcher driven by filesystem notifications
+func newNotifyWatcher() (fileWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	w := &inotifyWatcher{
+		fd: fd,
+		// A non-blocking descriptor goes through the runtime poller, so Close
+		// interrupts a pending Read (as long as nobody calls Fd, which makes it blocking)
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan string, 64),
+		dirs:   make(map[int32]string),
+		wds:    make(map[string]int32),
+	}
+	go w.read()
+	return w, nil
+}
+
+func (w *inotifyWatcher) Add(dir string) error {
+	dir = filepath.Clean(dir)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.wds[dir]; ok {
+		return nil
+	}
+
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+	w.dirs[int32(wd)] = dir
+	w.wds[dir] = int32(wd)
+	return nil
+}
+
+func (w *inotifyWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *inotifyWatcher) Close() error {
+	return w.file.Close()
+}
+
+// read decodes inotify events until the watcher is closed
+func (w *inotifyWatcher) read() {
+	defer close(w.events)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrClosed) {
+				return
+			}
+			w.events <- "" // Unknown state: treat everything as changed
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:nameStart+int(event.Len)]), "\x00")
+			offset = nameStart + int(event.Len)
+
+			if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				w.events <- "" // Events were dropped
+				continue
+			}
+
+			w.mu.Lock()
+			dir, ok := w.dirs[event.Wd]
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				// The watch is gone (directory deleted or unmounted)
+				delete(w.dirs, event.Wd)
+				delete(w.wds, dir)
+			}
+			w.mu.Unlock()
+
+			if !ok || event.Mask&syscall.IN_IGNORED != 0 {
+				continue
+			}
+			if name == "" {
+				w.events <- dir // The directory itself was deleted or moved
+			} else {
+				w.events <- filepath.Join(dir, name)
+			}
+		}
+	}
+}
+
+// enableRawInput makes a terminal deliver keys as they are pressed, without echoing
+// them. Ctrl+C still sends a signal. restore puts the previous settings back.
+func enableRawInput(f *os.File) (restore func(), err error) {
+	fd := f.Fd()
+	var previous syscall.Termios
+	if err := termios(fd, syscall.TCGETS, &previous); err != nil {
+		return nil, err
+	}
+
+	raw := previous
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := termios(fd, syscall.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+	return func() { termios(fd, syscall.TCSETS, &previous) }, nil
+}
+
+func termios(fd uintptr, request uintptr, t *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return os.NewSyscallError("ioctl", errno)
+	}
+	return nil
+}
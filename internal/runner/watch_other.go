@@ -0,0 +1,18 @@
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"os"
+)
+
+// newNotifyWatcher is only implemented on Linux; elsewhere watch mode polls
+func newNotifyWatcher() (fileWatcher, error) {
+	return nil, errors.New("filesystem notifications are not supported on this platform")
+}
+
+// enableRawInput is only implemented on Linux; elsewhere watch mode reads typed lines
+func enableRawInput(f *os.File) (func(), error) {
+	return nil, errors.New("raw terminal input is not supported on this platform")
+}
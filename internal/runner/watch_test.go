@@ -0,0 +1,346 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// expectEvent waits for the watcher to report path
+func expectEvent(t *testing.T, watcher fileWatcher, path string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got, ok := <-watcher.Events():
+			if !ok {
+				t.Fatalf("watcher closed while waiting for %s", path)
+			}
+			if got == path {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no event for %s", path)
+		}
+	}
+}
+
+func testWatcher(t *testing.T, watcher fileWatcher) {
+	dir := t.TempDir()
+	if err := watcher.Add(dir); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	file := filepath.Join(dir, "calc.js.vyb")
+	os.WriteFile(file, []byte("a"), 0644)
+	expectEvent(t, watcher, file)
+
+	renamed := filepath.Join(dir, "math.js.vyb")
+	os.Rename(file, renamed)
+	expectEvent(t, watcher, renamed)
+
+	os.Remove(renamed)
+	expectEvent(t, watcher, renamed)
+
+	watcher.Close()
+	for range watcher.Events() {
+		// Drain until the watcher shuts down
+	}
+}
+
+func TestNotifyWatcher(t *testing.T) {
+	watcher, err := newNotifyWatcher()
+	if err != nil {
+		t.Skipf("filesystem notifications unavailable: %v", err)
+	}
+	testWatcher(t, watcher)
+}
+
+func TestPollWatcher(t *testing.T) {
+	testWatcher(t, newPollWatcher(10*time.Millisecond))
+}
+
+// stoppedWatcher is a watcher whose events stopped, as when reading notifications fails
+type stoppedWatcher struct {
+	events chan string
+	closed bool
+}
+
+func (w *stoppedWatcher) Add(dir string) error  { return nil }
+func (w *stoppedWatcher) Events() <-chan string { return w.events }
+func (w *stoppedWatcher) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestWatchPollsWhenNotificationsStop(t *testing.T) {
+	dir := t.TempDir()
+	stopped := &stoppedWatcher{events: make(chan string)}
+	close(stopped.events)
+	s := &watchSession{pattern: dir, dir: dir, watcher: stopped}
+
+	if err := s.pollInstead(); err != nil {
+		t.Fatalf("pollInstead failed: %v", err)
+	}
+	defer s.watcher.Close()
+	if !stopped.closed {
+		t.Error("Expected the stopped watcher to be closed")
+	}
+
+	// The directories are watched again by polling
+	file := filepath.Join(dir, "calc.js.vyb")
+	os.WriteFile(file, []byte("a"), 0644)
+	expectEvent(t, s.watcher, file)
+
+	// There is nothing left to fall back to when polling stops
+	if err := s.pollInstead(); err == nil {
+		t.Error("Expected an error when the polling watcher stops")
+	}
+}
+
+func TestCollectChanges(t *testing.T) {
+	events := make(chan string, 10)
+	events <- "b.js"
+	events <- "a.vyb"
+
+	changed := collectChanges("a.vyb", events, 20*time.Millisecond, time.Second)
+	if !reflect.DeepEqual(changed, []string{"a.vyb", "b.js"}) {
+		t.Errorf("changed = %v", changed)
+	}
+
+	// A steady stream of changes is cut off at max
+	stream := make(chan string)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case stream <- "c.js":
+				time.Sleep(5 * time.Millisecond)
+			case <-stop:
+				close(stream)
+				return
+			}
+		}
+	}()
+	start := time.Now()
+	collectChanges("c.js", stream, 20*time.Millisecond, 100*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("burst took %v, want about 100ms", elapsed)
+	}
+	close(stop)
+}
+
+func TestAffectedTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	abs := func(name string) string { return filepath.Join(dir, name) }
+
+	previous := []string{abs("a.vyb"), abs("b.vyb"), abs("old.vyb")}
+	current := []string{abs("a.vyb"), abs("b.vyb"), abs("new.vyb")} // old.vyb renamed to new.vyb
+
+	files, removed, reason := affectedTestFiles(previous, current, []string{abs("b.vyb"), abs("old.vyb"), abs("new.vyb"), abs("notes.txt")}, nil, dir)
+	if !reflect.DeepEqual(files, []string{abs("b.vyb"), abs("new.vyb")}) || reason != "" {
+		t.Errorf("files = %v (%s)", files, reason)
+	}
+	if !reflect.DeepEqual(removed, []string{abs("old.vyb")}) {
+		t.Errorf("removed = %v", removed)
+	}
+
+	files, _, _ = affectedTestFiles(current, current, []string{abs("notes.txt")}, nil, dir)
+	if len(files) != 0 {
+		t.Errorf("Expected no files for unrelated changes, got %v", files)
+	}
+
+	files, _, reason = affectedTestFiles(current, current, []string{""}, nil, dir)
+	if len(files) != 3 || reason == "" {
+		t.Errorf("Expected a full run after lost events, got %v (%s)", files, reason)
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	now := time.Now()
+	before := map[string]time.Time{"a": now, "b": now, "c": now}
+	after := map[string]time.Time{"a": now, "b": now.Add(time.Second), "d": now}
+	if got := diffSnapshots(before, after); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+		t.Errorf("changed = %v", got)
+	}
+}
+
+func TestWatchCommands(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.WriteFile("a.vyb", []byte(`adds:
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 2"
+breaks:
+  when:
+    - x = 1
+  then:
+    - "expect: x == 2"
+`), 0644)
+	os.WriteFile("b.vyb", []byte(`also adds:
+  when:
+    - x = add(2, 2)
+  then:
+    - "expect: x == 4"
+`), 0644)
+
+	watcher := newPollWatcher(time.Hour)
+	defer watcher.Close()
+	keys := make(chan string, 10)
+	collector := &reportCollector{}
+	s := &watchSession{
+		pattern: ".",
+		opts:    Options{Reporters: []ReporterSpec{{Format: OutputJSON, Output: filepath.Join("out", "results.json")}}},
+		dir:     dir,
+		watcher: watcher,
+		keys:    keys,
+		raw:     true,
+		failed:  make(map[string]map[string]bool),
+	}
+	s.opts.observers = []Reporter{collector, &failureTracker{failed: s.failed}}
+
+	ran := func(run func()) []string {
+		collector.reports = nil
+		run()
+		var names []string
+		for _, report := range collector.reports {
+			names = append(names, report.Result.Name)
+		}
+		return names
+	}
+
+	s.refresh()
+	if names := ran(func() { s.run(s.files, "") }); len(names) != 3 {
+		t.Fatalf("initial run = %v", names)
+	}
+	if !reflect.DeepEqual(s.failed, map[string]map[string]bool{"a.vyb": {"breaks": true}}) {
+		t.Errorf("failed = %v", s.failed)
+	}
+
+	if names := ran(func() { s.command("f") }); !reflect.DeepEqual(names, []string{"breaks"}) {
+		t.Errorf("f ran %v", names)
+	}
+
+	// Typing "adx", backspace, "ds" in raw mode filters by test name "adds"
+	for _, key := range []string{"a", "d", "x", "\x7f", "d", "s", "\n"} {
+		keys <- key
+	}
+	if names := ran(func() { s.command("a"); s.command("t") }); !reflect.DeepEqual(names, []string{"adds", "breaks", "also adds", "adds", "also adds"}) {
+		t.Errorf("a, t ran %v", names)
+	}
+	if s.testPattern != "adds" || s.failedOnly {
+		t.Errorf("filters = %q", s.filters())
+	}
+
+	// Lines carry the value along with the key when input isn't raw
+	s.raw = false
+	if names := ran(func() { s.command("p b.vyb") }); !reflect.DeepEqual(names, []string{"also adds"}) {
+		t.Errorf("p ran %v", names)
+	}
+	if names := ran(func() { s.command("") }); !reflect.DeepEqual(names, []string{"also adds"}) {
+		t.Errorf("Enter ran %v", names)
+	}
+	if got := s.filters(); got != `file "b.vyb", test "adds"` {
+		t.Errorf("filters = %s", got)
+	}
+
+	if s.command("q") {
+		t.Error("Expected q to quit")
+	}
+}
+
+func TestMatchesFilePattern(t *testing.T) {
+	tests := []struct {
+		pattern, file string
+		want          bool
+	}{
+		{"calc", "tests/Calc.js.vyb", true},
+		{"tests/pay", "tests/payments/refund.vyb", true},
+		{"*.py.vyb", "tests/calc.py.vyb", true},
+		{"tests/*.vyb", "tests/payments/refund.vyb", false},
+		{"tests/**", "tests/payments/refund.vyb", true},
+		{"refund", "tests/calc.vyb", false},
+	}
+	for _, tt := range tests {
+		if got := matchesFilePattern(tt.pattern, tt.file); got != tt.want {
+			t.Errorf("matchesFilePattern(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+// keyPresser presses keys when the first test ends, as if typed during the run
+type keyPresser struct {
+	baseReporter
+	keys    chan<- string
+	pressed []string
+}
+
+func (r *keyPresser) TestEnd(report TestReport) {
+	for _, key := range r.pressed {
+		r.keys <- key // Unbuffered: returns once the session has handled the previous key
+	}
+	r.pressed = nil
+}
+
+func TestWatchQuitStopsRun(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.WriteFile("a.vyb", []byte(`adds:
+  when:
+    - x = add(1, 1)
+  then:
+    - "expect: x == 2"
+subtracts:
+  when:
+    - x = add(1, -1)
+  then:
+    - "expect: x == 0"
+`), 0644)
+	os.WriteFile("b.vyb", []byte(`also adds:
+  when:
+    - x = add(2, 2)
+  then:
+    - "expect: x == 4"
+`), 0644)
+
+	keys := make(chan string)
+	recorder := &eventRecorder{}
+	s := &watchSession{
+		pattern: ".",
+		opts:    Options{Reporters: []ReporterSpec{{Format: OutputJSON, Output: filepath.Join("out", "results.json")}}},
+		dir:     dir,
+		keys:    keys,
+		raw:     true,
+		failed:  make(map[string]map[string]bool),
+	}
+	s.opts.observers = []Reporter{&keyPresser{keys: keys, pressed: []string{"f", "q", "a"}}, recorder}
+
+	s.run([]string{"a.vyb", "b.vyb"}, "")
+
+	want := []string{
+		"start a.vyb", "pass adds", "not_run subtracts", "end a.vyb (0 failed, 1 not run)",
+		"start b.vyb", "not_run also adds", "end b.vyb (0 failed, 1 not run)",
+		"finish (3 total, 0 failed, 2 not run)",
+	}
+	if !reflect.DeepEqual(recorder.events, want) {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(recorder.events, "\n"), strings.Join(want, "\n"))
+	}
+	if !s.quit {
+		t.Error("Expected q to quit watch mode")
+	}
+	// Other keys typed during the run wait for it to finish
+	if !reflect.DeepEqual(s.pending, []string{"f", "a"}) {
+		t.Errorf("pending = %q", s.pending)
+	}
+}